@@ -1,26 +1,32 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/mitas/dcm/internal/cmd"
 	"github.com/mitas/dcm/internal/manager"
-	"github.com/mitas/dcm/pkg/formatter"
 )
 
 func main() {
 	// Initialize components
 	cmdExecutor := &manager.DefaultCommandExecutor{}
 	projectManager := manager.NewManager(cmdExecutor)
-	outputFormatter := formatter.NewFormatter()
 
 	// Initialize root command
-	rootCmd := cmd.NewRootCmd(projectManager, outputFormatter)
+	rootCmd := cmd.NewRootCmd(projectManager)
+
+	// Cancel running actions on Ctrl-C or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 
 	// Execute the application
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
 }
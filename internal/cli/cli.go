@@ -14,11 +14,11 @@ import (
 // CLI handles command line interface operations
 type CLI struct {
 	manager   *manager.Manager
-	formatter *formatter.Formatter
+	formatter formatter.Formatter
 }
 
 // NewCLI creates a new CLI
-func NewCLI(manager *manager.Manager, formatter *formatter.Formatter) *CLI {
+func NewCLI(manager *manager.Manager, formatter formatter.Formatter) *CLI {
 	return &CLI{
 		manager:   manager,
 		formatter: formatter,
@@ -46,9 +46,7 @@ func (c *CLI) Run(cfg *config.Config) error {
 		if cfg.ActionAll {
 			// Start all projects
 			results := c.manager.ManageAllProjects(ctx, projects, model.ActionStart)
-			for _, result := range results {
-				fmt.Println(c.formatter.FormatActionResult(result))
-			}
+			fmt.Println(c.formatter.FormatActionResults(results))
 		} else {
 			// Start specific project
 			project, found := c.manager.FindProject(projects, cfg.TargetProject)
@@ -58,7 +56,7 @@ func (c *CLI) Run(cfg *config.Config) error {
 			}
 
 			fmt.Println(c.formatter.FormatActionStart("Starting", project.Name))
-			result := c.manager.StartProject(project)
+			result := c.manager.StartProject(ctx, project)
 			fmt.Println(c.formatter.FormatActionResult(result))
 		}
 
@@ -66,9 +64,7 @@ func (c *CLI) Run(cfg *config.Config) error {
 		if cfg.ActionAll {
 			// Stop all projects
 			results := c.manager.ManageAllProjects(ctx, projects, model.ActionStop)
-			for _, result := range results {
-				fmt.Println(c.formatter.FormatActionResult(result))
-			}
+			fmt.Println(c.formatter.FormatActionResults(results))
 		} else {
 			// Stop specific project
 			project, found := c.manager.FindProject(projects, cfg.TargetProject)
@@ -78,22 +74,19 @@ func (c *CLI) Run(cfg *config.Config) error {
 			}
 
 			fmt.Println(c.formatter.FormatActionStart("Stopping", project.Name))
-			result := c.manager.StopProject(project)
+			result := c.manager.StopProject(ctx, project)
 			fmt.Println(c.formatter.FormatActionResult(result))
 		}
 
 	case model.ActionStatus:
 		if cfg.ActionAll {
 			// Check status of all projects
-			fmt.Printf("🔍 Checking status of %d Docker Compose projects...\n", len(projects))
+			fmt.Println(c.formatter.FormatBulkActionStart("Checking status of", len(projects)))
+			statuses := make([]model.ProjectStatus, 0, len(projects))
 			for _, project := range projects {
-				isRunning, services, err := c.manager.CheckProjectStatus(project)
-				if err != nil {
-					fmt.Printf("❌ Error checking status of %s: %v\n", project.Name, err)
-					continue
-				}
-				fmt.Println(c.formatter.FormatProjectStatus(project.Name, project.Path, isRunning, services))
+				statuses = append(statuses, c.manager.GetProjectStatus(project))
 			}
+			fmt.Println(c.formatter.FormatStatusList(statuses))
 		} else {
 			// Check status of specific project
 			project, found := c.manager.FindProject(projects, cfg.TargetProject)
@@ -103,11 +96,11 @@ func (c *CLI) Run(cfg *config.Config) error {
 			}
 
 			fmt.Println(c.formatter.FormatActionStart("Checking status of", project.Name))
-			isRunning, services, err := c.manager.CheckProjectStatus(project)
-			if err != nil {
-				return fmt.Errorf("error checking status of %s: %w", project.Name, err)
+			status := c.manager.GetProjectStatus(project)
+			if status.Error != nil {
+				return fmt.Errorf("error checking status of %s: %w", project.Name, status.Error)
 			}
-			fmt.Println(c.formatter.FormatProjectStatus(project.Name, project.Path, isRunning, services))
+			fmt.Println(c.formatter.FormatProjectStatus(status))
 		}
 	}
 
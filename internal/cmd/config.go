@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mitas/dcm/internal/config"
+	"github.com/mitas/dcm/internal/manager"
+	"github.com/mitas/dcm/internal/model"
+)
+
+// newConfigCmd creates the config command grouping config file maintenance
+func newConfigCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and maintain the dcm config file",
+		Long: `Inspect and maintain the dcm config file holding managed projects and settings.
+These commands still run with the default settings when the config file is
+broken, so it can be repaired.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.resolveRecovering(cmd, projectManager)
+		},
+	}
+
+	cmd.AddCommand(newConfigPathCmd(opts))
+	cmd.AddCommand(newConfigShowCmd(opts))
+	cmd.AddCommand(newConfigEditCmd(opts))
+	cmd.AddCommand(newConfigValidateCmd(opts))
+	cmd.AddCommand(newConfigMigrateCmd(opts))
+
+	return cmd
+}
+
+// newConfigPathCmd creates a command printing the config file location
+func newConfigPathCmd(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "path",
+		Short: "Print the path of the config file",
+		Long:  `Print the path of the config file dcm uses, whether or not it exists yet.`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printOutput(cmd, opts.Formatter.FormatConfigPath(opts.resolvedConfigPath()))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// newConfigShowCmd creates a command printing the effective config
+func newConfigShowCmd(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show the effective config",
+		Long: `Show the config as dcm understands it, after migrations and with unknown fields removed.
+The managed projects and settings are those of the selected context.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			managedConfig, err := opts.loadConfig()
+			if err != nil {
+				return fmt.Errorf("error loading config: %w", err)
+			}
+
+			data, err := config.MarshalManagedConfig(managedConfig)
+			if err != nil {
+				return err
+			}
+
+			printOutput(cmd, opts.Formatter.FormatConfig(data))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// newConfigEditCmd creates a command opening the config file in an editor
+func newConfigEditCmd(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the config file in $EDITOR",
+		Long: `Open a copy of the config file in $VISUAL or $EDITOR (vi by default).
+The edited file is validated before it replaces the config, and invalid
+YAML is refused without touching the config file.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := opts.resolvedConfigPath()
+
+			original, err := os.ReadFile(path)
+			if os.IsNotExist(err) {
+				original, err = config.MarshalManagedConfig(&config.ManagedConfig{Projects: []model.ManagedProject{}})
+			}
+			if err != nil {
+				return fmt.Errorf("error reading config file: %w", err)
+			}
+
+			tmp, err := os.CreateTemp("", "dcm-config-*.yaml")
+			if err != nil {
+				return fmt.Errorf("error creating temporary file: %w", err)
+			}
+			tmpPath := tmp.Name()
+			_, err = tmp.Write(original)
+			if closeErr := tmp.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(tmpPath)
+				return fmt.Errorf("error writing temporary file: %w", err)
+			}
+
+			if err := runEditor(tmpPath); err != nil {
+				os.Remove(tmpPath)
+				return err
+			}
+
+			edited, err := os.ReadFile(tmpPath)
+			if err != nil {
+				return fmt.Errorf("error reading edited config: %w", err)
+			}
+
+			if bytes.Equal(edited, original) {
+				os.Remove(tmpPath)
+				printOutput(cmd, opts.Formatter.FormatConfigSaved(path, false))
+				return nil
+			}
+
+			managedConfig, err := opts.store.ReplaceManagedConfig(path, edited)
+			if err != nil {
+				return fmt.Errorf("config not saved, your changes are kept in %s: %w", tmpPath, err)
+			}
+			os.Remove(tmpPath)
+
+			for _, issue := range config.ValidateManagedConfig(managedConfig) {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s: %s\n", issue.Alias, issue.Message)
+			}
+
+			printOutput(cmd, opts.Formatter.FormatConfigSaved(path, true))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// runEditor opens path in the user's editor, attached to the terminal
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The editor may be given with arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	editorCmd := exec.Command(fields[0], append(fields[1:], path)...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+
+	if err := editorCmd.Run(); err != nil {
+		return fmt.Errorf("error running editor %s: %w", editor, err)
+	}
+	return nil
+}
+
+// newConfigValidateCmd creates a command checking the managed projects in the config
+func newConfigValidateCmd(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check that every managed project still exists",
+		Long: `Check that the directory and compose file of every managed project
+still exist and that no alias or path is registered twice, in every context.
+Problems in a context other than the default one are reported as
+context/alias. Exits with an error when problems are found.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result := model.ValidationResult{Path: opts.resolvedConfigPath()}
+
+			// A config that cannot be loaded is reported like any other problem
+			managedConfig, err := opts.store.LoadManagedConfig(opts.ConfigPath)
+			if err != nil {
+				result.Issues = append(result.Issues, model.ConfigIssue{Alias: "config", Message: err.Error()})
+			} else {
+				if _, err := buildTheme(managedConfig.Theme); err != nil {
+					result.Issues = append(result.Issues, model.ConfigIssue{Alias: "theme", Message: err.Error()})
+				}
+				result.Issues = append(result.Issues, validateContexts(opts.store, opts.ConfigPath, managedConfig)...)
+			}
+			printOutput(cmd, opts.Formatter.FormatValidation(result))
+
+			if len(result.Issues) > 0 {
+				return fmt.Errorf("config has %d problem(s)", len(result.Issues))
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// validateContexts checks the managed projects of every context, naming
+// the context in the issues outside the default one
+func validateContexts(store *config.Store, configPath string, rootConfig *config.ManagedConfig) []model.ConfigIssue {
+	var issues []model.ConfigIssue
+	for _, name := range rootConfig.ContextNames() {
+		managedConfig, err := store.LoadContextConfig(configPath, name)
+		if err != nil {
+			issues = append(issues, model.ConfigIssue{Alias: name, Message: err.Error()})
+			continue
+		}
+
+		for _, issue := range config.ValidateManagedConfig(managedConfig) {
+			if name != config.DefaultContext {
+				issue.Alias = name + "/" + issue.Alias
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// newConfigMigrateCmd creates a command to upgrade the config file schema
+func newConfigMigrateCmd(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade the config file to the current schema version",
+		Long: `Upgrade the config file to the current schema version, step by step.
+The previous file is kept next to it as a versioned .bak file.
+Use --dry-run to only show the changes.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := opts.store.MigrateManagedConfig(opts.ConfigPath, opts.DryRun)
+			if err != nil {
+				return err
+			}
+
+			printOutput(cmd, opts.Formatter.FormatMigration(result))
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConfigMigrateDryRun(t *testing.T) {
+	configPath := isolate(t, "projects: []\n")
+
+	for _, args := range [][]string{{"--dry-run", "config", "migrate"}, {"config", "migrate", "--dry-run"}} {
+		stdout, stderr, err := runDcm(t, strings.NewReader(""), args...)
+		if err != nil {
+			t.Fatalf("%v: %s", err, stderr)
+		}
+		if !strings.Contains(stdout, "would be migrated") {
+			t.Errorf("%v printed %q, want a dry run", args, stdout)
+		}
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "projects: []\n" {
+			t.Errorf("%v changed the config:\n%s", args, data)
+		}
+	}
+}
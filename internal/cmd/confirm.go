@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mitas/dcm/internal/model"
+	"github.com/mitas/dcm/pkg/formatter"
+)
+
+// confirm asks the user to confirm a bulk or destructive action, described
+// as e.g. "stop 3 projects". --yes skips the question, as does --dry-run,
+// which only prints the plan; when stdin is not a terminal to ask on, the
+// action is refused.
+func (o *Options) confirm(cmd *cobra.Command, action string) error {
+	if o.Yes || o.DryRun {
+		return nil
+	}
+
+	in := cmd.InOrStdin()
+	if file, ok := in.(*os.File); !ok || !formatter.IsTerminal(file) {
+		return fmt.Errorf("refusing to %s without confirmation as stdin is not a terminal, pass --yes to confirm", action)
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "About to %s. Continue? [y/N] ", action)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("error reading confirmation: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("aborted, nothing was done")
+}
+
+// projectCount describes a number of projects with their names
+func projectCount(projects []model.Project) string {
+	names := make([]string, 0, len(projects))
+	for _, p := range projects {
+		names = append(names, p.Name)
+	}
+	if len(projects) == 1 {
+		return "1 project (" + names[0] + ")"
+	}
+	return fmt.Sprintf("%d projects (%s)", len(projects), strings.Join(names, ", "))
+}
+
+// protectedError explains that a protected managed project needs --force
+func protectedError(managedProject model.ManagedProject, action string) error {
+	return fmt.Errorf("managed project '%s' is protected, use --force to %s it", managedProject.Alias, action)
+}
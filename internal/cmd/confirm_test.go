@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfirmStopAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		refused bool
+		stopped bool
+	}{
+		{"not a terminal", nil, true, false},
+		{"yes", []string{"--yes"}, false, true},
+		{"dry run", []string{"--dry-run"}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, dir := managedProjectConfig(t)
+			calls := filepath.Join(t.TempDir(), "calls")
+			fakeDocker(t, "echo \"$*\" >> "+calls+"\n")
+
+			// /dev/null is a character device, yet no terminal to ask on
+			in, err := os.Open(os.DevNull)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer in.Close()
+
+			args := append([]string{"--compose-backend", "docker", "--path", filepath.Dir(dir)}, tt.args...)
+			stdout, stderr, err := runDcm(t, in, append(args, "stop", "--all")...)
+			if refused := err != nil && strings.Contains(err.Error(), "pass --yes"); refused != tt.refused {
+				t.Fatalf("got %v: %s, want refused %v", err, stderr, tt.refused)
+			}
+			if strings.Contains(stderr, "Continue?") {
+				t.Errorf("asked for confirmation: %s", stderr)
+			}
+
+			data, _ := os.ReadFile(calls)
+			if stopped := strings.Contains(string(data), "down"); stopped != tt.stopped {
+				t.Errorf("docker was run with %q, want stopped %v", data, tt.stopped)
+			}
+			if tt.name == "dry run" && !strings.Contains(stdout, "down") {
+				t.Errorf("output is:\n%s\nwant the planned command", stdout)
+			}
+		})
+	}
+}
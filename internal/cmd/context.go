@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mitas/dcm/internal/config"
+	"github.com/mitas/dcm/internal/model"
+)
+
+// newContextCmd creates the command group for switching between sets of managed projects
+func newContextCmd(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "context",
+		Short: "Switch between sets of managed projects and settings",
+		Long: `Contexts are named sets of managed projects and settings, such as work
+and personal. The projects at the top level of the config file form the
+default context. A context is stored inline in the config file, or in a
+separate config file given with 'context create --file'.
+
+The context in use is chosen with --context, then DCM_CONTEXT, then the
+current context set with 'dcm context use'.`,
+	}
+
+	cmd.AddCommand(newContextListCmd(opts))
+	cmd.AddCommand(newContextUseCmd(opts))
+	cmd.AddCommand(newContextCreateCmd(opts))
+	cmd.AddCommand(newContextDeleteCmd(opts))
+
+	return cmd
+}
+
+// newContextListCmd creates a command listing the contexts
+func newContextListCmd(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the contexts, marking the one in use",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath := opts.resolvedConfigPath()
+			rootConfig, err := opts.store.LoadManagedConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading config: %w", err)
+			}
+
+			var contexts []model.ContextInfo
+			for _, name := range rootConfig.ContextNames() {
+				contextConfig, err := opts.store.LoadContextConfig(configPath, name)
+				if err != nil {
+					return fmt.Errorf("error loading context '%s': %w", name, err)
+				}
+
+				info := model.ContextInfo{
+					Name:     name,
+					Current:  name == opts.Context || (name == config.DefaultContext && opts.Context == ""),
+					Projects: len(contextConfig.Projects),
+				}
+				if context := rootConfig.Contexts[name]; context != nil {
+					info.File = context.File
+				}
+				contexts = append(contexts, info)
+			}
+
+			printOutput(cmd, opts.Formatter.FormatContexts(contexts))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// newContextUseCmd creates a command changing the current context
+func newContextUseCmd(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use [context]",
+		Short: "Make a context the current one",
+		Long:  `Make a context the current one. Use 'default' to go back to the top-level projects.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			err := opts.store.UpdateManagedConfig(opts.ConfigPath, func(managedConfig *config.ManagedConfig) error {
+				return config.UseContext(managedConfig, name)
+			})
+			if err != nil {
+				return fmt.Errorf("error switching context: %w", err)
+			}
+
+			printOutput(cmd, opts.Formatter.FormatActionResult(model.Result{
+				Success: true,
+				Message: fmt.Sprintf("Switched to context '%s'", name),
+			}))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// newContextCreateCmd creates a command adding an empty context
+func newContextCreateCmd(opts *Options) *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "create [context]",
+		Short: "Add an empty context",
+		Long: `Add an empty context. With --file, the managed projects and settings of
+the context are kept in that config file, relative to the main config file,
+which is created on the first change.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			err := opts.store.UpdateManagedConfig(opts.ConfigPath, func(managedConfig *config.ManagedConfig) error {
+				return config.CreateContext(managedConfig, name, file)
+			})
+			if err != nil {
+				return fmt.Errorf("error creating context: %w", err)
+			}
+
+			printOutput(cmd, opts.Formatter.FormatActionResult(model.Result{
+				Success: true,
+				Message: fmt.Sprintf("Created context '%s', switch to it with: dcm context use %s", name, name),
+			}))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "Config file storing the context, relative to the main config file")
+
+	return cmd
+}
+
+// newContextDeleteCmd creates a command removing a context
+func newContextDeleteCmd(opts *Options) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:     "delete [context]",
+		Aliases: []string{"rm"},
+		Short:   "Remove a context",
+		Long: `Remove a context and its managed projects. The config file of a
+file-backed context is left in place. Deleting the current context switches
+back to the default context.
+
+Deleting a context with managed projects in the main config file asks for
+confirmation first, pass --yes to skip it. A context with protected projects
+is only deleted with --force.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			rootConfig, err := opts.store.LoadManagedConfig(opts.resolvedConfigPath())
+			if err != nil {
+				return fmt.Errorf("error loading config: %w", err)
+			}
+			if context := rootConfig.Contexts[name]; context != nil && len(context.Projects) > 0 {
+				projects := make([]model.Project, 0, len(context.Projects))
+				for _, p := range context.Projects {
+					if p.Protected && !force {
+						return fmt.Errorf("context '%s' has protected managed project '%s', use --force to delete it", name, p.Alias)
+					}
+					projects = append(projects, p.Project)
+				}
+				if err := opts.confirm(cmd, "delete context "+name+" with "+projectCount(projects)); err != nil {
+					return err
+				}
+			}
+
+			var deleted *config.Context
+			err = opts.store.UpdateManagedConfig(opts.ConfigPath, func(managedConfig *config.ManagedConfig) error {
+				var err error
+				deleted, err = config.DeleteContext(managedConfig, name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("error deleting context: %w", err)
+			}
+
+			message := fmt.Sprintf("Deleted context '%s'", name)
+			if deleted.File != "" {
+				message += fmt.Sprintf(", its config file %s was kept", deleted.File)
+			}
+			printOutput(cmd, opts.Formatter.FormatActionResult(model.Result{Success: true, Message: message}))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Delete a context with protected projects too")
+
+	return cmd
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mitas/dcm/internal/manager"
+)
+
+// newInspectCmd creates a command showing the effective settings of a project
+func newInspectCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "inspect [project]",
+		Short: "Show the effective settings of a project",
+		Long: `Show the settings used to run a project: its managed settings merged
+over the .dcm.yaml found next to its compose file. Each setting names the
+layer it comes from; a setting in the managed config replaces the local one.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := opts.commandFormatter(format)
+			if err != nil {
+				return err
+			}
+
+			projectName := args[0]
+
+			if !opts.PathsSet {
+				managedConfig, err := opts.loadConfig()
+				if err != nil {
+					return fmt.Errorf("error loading managed projects: %w", err)
+				}
+
+				managedProject, found := projectManager.GetManagedProject(managedConfig, projectName)
+				if found {
+					if err := checkManagedProject(managedProject); err != nil {
+						return err
+					}
+
+					inspection, err := projectManager.InspectProject(managedProject.Alias, managedProject.Project)
+					if err != nil {
+						return err
+					}
+					printOutput(cmd, out.FormatInspection(inspection))
+					return nil
+				}
+
+				if len(opts.Paths) == 0 {
+					return fmt.Errorf("project '%s' not found in managed projects and no --path provided", projectName)
+				}
+			}
+
+			projects, err := opts.findProjects(projectManager)
+			if err != nil {
+				return fmt.Errorf("error finding projects: %w", err)
+			}
+
+			project, found := projectManager.FindProject(projects, projectName)
+			if !found {
+				return fmt.Errorf("project '%s' not found in %s", projectName, opts.pathsDescription())
+			}
+
+			inspection, err := projectManager.InspectProject("", project)
+			if err != nil {
+				return err
+			}
+			printOutput(cmd, out.FormatInspection(inspection))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "", "Format output using a Go template, e.g. '{{.Project.Profiles}}'")
+
+	return cmd
+}
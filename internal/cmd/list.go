@@ -6,27 +6,39 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/mitas/dcm/internal/manager"
-	"github.com/mitas/dcm/pkg/formatter"
 )
 
 // newListCmd creates the list command
-func newListCmd(projectManager *manager.Manager, outputFormatter *formatter.Formatter) *cobra.Command {
+func newListCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	var format string
+
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all docker-compose projects",
 		Long:  `Find and list all docker-compose projects in the specified path.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := opts.commandFormatter(format)
+			if err != nil {
+				return err
+			}
+
+			if len(opts.Paths) == 0 {
+				return fmt.Errorf("path is required to find projects, use --path flag")
+			}
+
 			// Find all docker-compose projects
-			projects, err := projectManager.FindProjects(rootPath)
+			projects, err := opts.findProjects(projectManager)
 			if err != nil {
 				return fmt.Errorf("error finding projects: %w", err)
 			}
 
 			// Print the formatted list
-			fmt.Println(outputFormatter.FormatProjectList(projects))
+			printOutput(cmd, out.FormatProjectList(projects))
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&format, "format", "", "Format output using a Go template, e.g. '{{.Name}}\t{{.Path}}'")
+
 	return cmd
 }
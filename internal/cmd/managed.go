@@ -2,80 +2,97 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/mitas/dcm/internal/config"
 	"github.com/mitas/dcm/internal/manager"
-	"github.com/mitas/dcm/pkg/formatter"
+	"github.com/mitas/dcm/internal/model"
 )
 
 // newListManagedCmd creates a command to list managed projects
-func newListManagedCmd(projectManager *manager.Manager, outputFormatter *formatter.Formatter) *cobra.Command {
+func newListManagedCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	var format string
+
 	cmd := &cobra.Command{
 		Use:     "list-managed",
 		Aliases: []string{"lsm", "lm"},
 		Short:   "List all managed docker-compose projects",
 		Long:    `List all docker-compose projects that have been saved to the config file.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := opts.commandFormatter(format)
+			if err != nil {
+				return err
+			}
+
 			// Load managed config
-			managedConfig, err := config.LoadManagedConfig(configPath)
+			managedConfig, err := opts.loadConfig()
 			if err != nil {
 				return fmt.Errorf("error loading managed projects: %w", err)
 			}
 
 			// Format and display managed projects
-			if len(managedConfig.Projects) == 0 {
-				fmt.Printf("%sNo managed projects found%s\n", formatter.ColorYellow, formatter.ColorReset)
-				return nil
-			}
-
-			fmt.Printf("%s📋 Managed Projects:%s\n", formatter.ColorBold, formatter.ColorReset)
-			for i, p := range managedConfig.Projects {
-				fmt.Printf("%s📌 %d.%s %s%s%s (alias) -> %s%s%s (%s)\n",
-					formatter.ColorBlue, i+1, formatter.ColorReset,
-					formatter.ColorBold, p.Alias, formatter.ColorReset,
-					formatter.ColorGreen, p.Project.Name, formatter.ColorReset,
-					p.Project.Path)
-			}
-
+			printOutput(cmd, out.FormatManagedProjectsList(managedConfig.Projects))
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&format, "format", "", "Format output using a Go template, e.g. '{{.Alias}}\t{{.Project.Path}}'")
+
 	return cmd
 }
 
 // newAddManagedCmd creates a command to add a managed project
-func newAddManagedCmd(projectManager *manager.Manager, outputFormatter *formatter.Formatter) *cobra.Command {
-	var alias string
+func newAddManagedCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	var (
+		alias         string
+		all           bool
+		aliasTemplate string
+	)
 
 	cmd := &cobra.Command{
-		Use:     "add-managed [project]",
+		Use:     "add-managed [project...]",
 		Aliases: []string{"add"},
-		Short:   "Add a project to managed projects",
-		Long:    `Add a docker-compose project to the managed projects list with an optional alias.`,
+		Short:   "Add projects to managed projects",
+		Long: `Add a docker-compose project to the managed projects list with an optional alias.
+
+Several projects can be added at once with --all or with selectors: a
+selector containing *, ? or [ is a glob matched against the whole project
+name, any other selector matches part of the name. Aliases are then
+generated with --alias-template, projects that are already managed are
+skipped and alias conflicts are reported without stopping the others.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if rootPath == "" {
+			if len(opts.Paths) == 0 {
 				return fmt.Errorf("path is required to find projects, use --path flag")
 			}
 
-			if len(args) < 1 {
-				return fmt.Errorf("project name is required")
+			if len(args) < 1 && !all {
+				return fmt.Errorf("project name is required, or use --all")
 			}
 
-			projectName := args[0]
-
 			// Find projects in the specified path
-			projects, err := projectManager.FindProjects(rootPath)
+			projects, err := opts.findProjects(projectManager)
 			if err != nil {
 				return fmt.Errorf("error finding projects: %w", err)
 			}
 
+			if all || len(args) > 1 || cmd.Flags().Changed("alias-template") || isSelectorPattern(args[0]) {
+				if alias != "" {
+					return fmt.Errorf("--alias can only be used when adding a single project, use --alias-template")
+				}
+				return addManagedProjects(cmd, projectManager, opts, projects, args, all, aliasTemplate)
+			}
+
+			projectName := args[0]
+
 			// Find the target project
 			project, found := projectManager.FindProject(projects, projectName)
 			if !found {
-				return fmt.Errorf("project '%s' not found in %s", projectName, rootPath)
+				return fmt.Errorf("project '%s' not found in %s", projectName, opts.pathsDescription())
 			}
 
 			// If alias is not provided, use the project name
@@ -83,43 +100,89 @@ func newAddManagedCmd(projectManager *manager.Manager, outputFormatter *formatte
 				alias = project.Name
 			}
 
-			// Load managed config
-			managedConfig, err := config.LoadManagedConfig(configPath)
+			// Add the project to managed projects while holding the config lock
+			err = opts.updateConfig(func(managedConfig *config.ManagedConfig) error {
+				if err := projectManager.AddManagedProject(managedConfig, project, alias); err != nil {
+					return fmt.Errorf("error adding managed project: %w", err)
+				}
+				return nil
+			})
 			if err != nil {
-				return fmt.Errorf("error loading managed projects: %w", err)
+				return err
 			}
 
-			// Add the project to managed projects
-			if err := projectManager.AddManagedProject(managedConfig, project, alias); err != nil {
-				return fmt.Errorf("error adding managed project: %w", err)
-			}
-
-			// Save managed config
-			if err := config.SaveManagedConfig(managedConfig, configPath); err != nil {
-				return fmt.Errorf("error saving managed projects: %w", err)
-			}
-
-			fmt.Printf("%s✅ Project '%s%s%s' added to managed projects with alias '%s%s%s'%s\n",
-				formatter.ColorGreen,
-				formatter.ColorBold, project.Name, formatter.ColorReset+formatter.ColorGreen,
-				formatter.ColorBold, alias, formatter.ColorReset+formatter.ColorGreen,
-				formatter.ColorReset)
+			printOutput(cmd, opts.Formatter.FormatManagedProjectAdded(model.ManagedProject{
+				Alias:   alias,
+				Project: project,
+			}))
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&alias, "alias", "a", "", "Alias for the managed project (defaults to project name)")
+	cmd.Flags().BoolVar(&all, "all", false, "Add every project found in the path")
+	cmd.Flags().StringVar(&aliasTemplate, "alias-template", manager.DefaultAliasTemplate,
+		"Go template for aliases when adding several projects, with .Name, .ParentDir, .Path and .File")
 
 	return cmd
 }
 
+// addManagedProjects adds every project matching the selectors in one config update
+func addManagedProjects(cmd *cobra.Command, projectManager *manager.Manager, opts *Options, projects []model.Project, selectors []string, all bool, aliasTemplate string) error {
+	aliases, err := manager.ParseAliasTemplate(aliasTemplate)
+	if err != nil {
+		return err
+	}
+
+	if !all {
+		projects, err = projectManager.SelectProjects(projects, selectors)
+		if err != nil {
+			return err
+		}
+	}
+	if len(projects) == 0 {
+		printOutput(cmd, opts.Formatter.FormatNoProjectsFound())
+		return nil
+	}
+
+	var results []model.Result
+	err = opts.updateConfig(func(managedConfig *config.ManagedConfig) error {
+		results = projectManager.AddManagedProjects(managedConfig, projects, aliases)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	printOutput(cmd, opts.Formatter.FormatActionResults(results))
+
+	failed := 0
+	for _, result := range results {
+		if !result.Success {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d project(s) could not be added", failed, len(results))
+	}
+	return nil
+}
+
+// isSelectorPattern reports whether a project argument is a glob selector
+func isSelectorPattern(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
 // newRemoveManagedCmd creates a command to remove a managed project
-func newRemoveManagedCmd(projectManager *manager.Manager, outputFormatter *formatter.Formatter) *cobra.Command {
+func newRemoveManagedCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	var force bool
+
 	cmd := &cobra.Command{
 		Use:     "remove-managed [alias]",
 		Aliases: []string{"rm"},
 		Short:   "Remove a project from managed projects",
-		Long:    `Remove a docker-compose project from the managed projects list using its alias.`,
+		Long: `Remove a docker-compose project from the managed projects list using its alias.
+Protected projects are only removed with --force.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) < 1 {
 				return fmt.Errorf("project alias is required")
@@ -127,29 +190,625 @@ func newRemoveManagedCmd(projectManager *manager.Manager, outputFormatter *forma
 
 			alias := args[0]
 
-			// Load managed config
-			managedConfig, err := config.LoadManagedConfig(configPath)
+			// Remove the project from managed projects while holding the config lock
+			var removed model.ManagedProject
+			err := opts.updateConfig(func(managedConfig *config.ManagedConfig) error {
+				for _, p := range managedConfig.Projects {
+					if p.Alias == alias && p.Protected && !force {
+						return protectedError(p, "remove")
+					}
+				}
+
+				var err error
+				removed, err = projectManager.RemoveManagedProject(managedConfig, alias)
+				if err != nil {
+					return fmt.Errorf("error removing managed project: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			printOutput(cmd, opts.Formatter.FormatManagedProjectRemoved(removed))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Remove the project even if it is protected")
+
+	return cmd
+}
+
+// newManagedCmd creates the command grouping managed project maintenance
+func newManagedCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "managed",
+		Aliases: []string{"m"},
+		Short:   "Maintain managed projects",
+		Long:    `Maintain the docker-compose projects saved to the config file.`,
+	}
+
+	cmd.AddCommand(newManagedPruneCmd(projectManager, opts))
+	cmd.AddCommand(newManagedRelocateCmd(projectManager, opts))
+	cmd.AddCommand(newManagedRepairCmd(projectManager, opts))
+	cmd.AddCommand(newManagedRenameCmd(projectManager, opts))
+	cmd.AddCommand(newManagedSetCmd(projectManager, opts))
+	cmd.AddCommand(newManagedExportCmd(projectManager, opts))
+	cmd.AddCommand(newManagedImportCmd(projectManager, opts))
+
+	return cmd
+}
+
+// newManagedPruneCmd creates a command removing managed projects that no longer exist
+func newManagedPruneCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove managed projects whose directory or compose file is gone",
+		Long: `Remove every managed project whose directory or compose file no longer exists.
+Asks for confirmation first, pass --yes to skip it. Protected projects are
+kept unless --force is given.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			managedConfig, err := opts.loadConfig()
+			if err != nil {
+				return fmt.Errorf("error loading managed projects: %w", err)
+			}
+			var stale []model.Project
+			for _, p := range managedConfig.Projects {
+				if config.CheckProjectFiles(p.Project) != "" && (force || !p.Protected) {
+					stale = append(stale, p.Project)
+				}
+			}
+			if len(stale) > 0 {
+				if err := opts.confirm(cmd, "remove "+projectCount(stale)+" from managed projects"); err != nil {
+					return err
+				}
+			}
+
+			var pruned []model.Result
+			err = opts.updateConfig(func(managedConfig *config.ManagedConfig) error {
+				pruned = projectManager.PruneManagedProjects(managedConfig, force)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			printOutput(cmd, opts.Formatter.FormatActionResults(pruned))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Prune protected projects too")
+
+	return cmd
+}
+
+// newManagedRelocateCmd creates a command pointing a managed project at a new location
+func newManagedRelocateCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "relocate [alias]",
+		Short: "Point a managed project at its new location",
+		Long: `Point a managed project at the directory given with --path. If that
+directory has no compose file, it is searched for a project with the
+same name instead.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(opts.Paths) == 0 {
+				return fmt.Errorf("path is required to relocate a project, use --path flag")
+			}
+
+			var result model.Result
+			err := opts.updateConfig(func(managedConfig *config.ManagedConfig) error {
+				managedProject, found := projectManager.GetManagedProject(managedConfig, args[0])
+				if !found {
+					return fmt.Errorf("no project found with alias '%s'", args[0])
+				}
+
+				project, err := findMovedProject(projectManager, opts, managedProject.Project)
+				if err != nil {
+					return err
+				}
+
+				relocated, err := projectManager.RelocateManagedProject(managedConfig, managedProject.Alias, project)
+				if err != nil {
+					return fmt.Errorf("error relocating managed project: %w", err)
+				}
+
+				result = model.Result{
+					Project: relocated.Project,
+					Success: true,
+					Message: fmt.Sprintf("Relocated '%s' to %s", relocated.Alias, relocated.Project.Path),
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			printOutput(cmd, opts.Formatter.FormatActionResult(result))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// newManagedRepairCmd creates a command relocating every stale managed project
+func newManagedRepairCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repair",
+		Short: "Find moved managed projects again under --path",
+		Long: `Rescan --path for every managed project whose directory or compose file
+is gone, and point it at the project with the same name found there.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(opts.Paths) == 0 {
+				return fmt.Errorf("path is required to search for moved projects, use --path flag")
+			}
+
+			var results []model.Result
+			err := opts.updateConfig(func(managedConfig *config.ManagedConfig) error {
+				for _, managedProject := range managedConfig.Projects {
+					if config.CheckProjectFiles(managedProject.Project) == "" {
+						continue
+					}
+
+					project, err := findMovedProject(projectManager, opts, managedProject.Project)
+					if err == nil {
+						_, err = projectManager.RelocateManagedProject(managedConfig, managedProject.Alias, project)
+					}
+					if err != nil {
+						results = append(results, model.Result{
+							Project: managedProject.Project,
+							Success: false,
+							Error:   fmt.Errorf("could not repair '%s': %w", managedProject.Alias, err),
+						})
+						continue
+					}
+
+					results = append(results, model.Result{
+						Project: project,
+						Success: true,
+						Message: fmt.Sprintf("Relocated '%s' to %s", managedProject.Alias, project.Path),
+					})
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			printOutput(cmd, opts.Formatter.FormatActionResults(results))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// newManagedRenameCmd creates a command changing the alias of a managed project
+func newManagedRenameCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename [alias] [new-alias]",
+		Short: "Change the alias of a managed project",
+		Long:  `Change the alias of a managed project, keeping the project it points to.`,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldAlias, newAlias := args[0], args[1]
+
+			var result model.Result
+			err := opts.updateConfig(func(managedConfig *config.ManagedConfig) error {
+				renamed, err := projectManager.RenameManagedProject(managedConfig, oldAlias, newAlias)
+				if err != nil {
+					return fmt.Errorf("error renaming managed project: %w", err)
+				}
+
+				result = model.Result{
+					Project: renamed.Project,
+					Success: true,
+					Message: fmt.Sprintf("Renamed '%s' to '%s'", oldAlias, newAlias),
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			printOutput(cmd, opts.Formatter.FormatActionResult(result))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// newManagedSetCmd creates a command changing the project a managed alias points to
+func newManagedSetCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	var (
+		name          string
+		file          string
+		envFiles      []string
+		composeFiles  []string
+		profiles      []string
+		dependsOn     []string
+		dockerContext string
+		dockerHost    string
+		env           []string
+		backend       string
+		protected     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "set [alias]",
+		Short: "Change the settings of a managed project",
+		Long: `Change the settings of a managed project in place. Only the given
+flags are changed; --path moves the project to another directory. List
+flags such as --env-file replace the whole list (pass them empty to clear
+it), and override the same setting from the project's .dcm.yaml.
+
+--docker-context and --docker-host select the Docker engine the project runs
+on; setting one clears the other, and passing it empty goes back to the
+engine selected by the environment. --env sets KEY=value variables for
+compose commands and hooks, replacing the previous ones. --backend selects
+the compose engine of the project, pass it empty to use the default; the
+global --compose-backend only applies to the current run.
+--protected requires --force to stop or remove the project, --protected=false
+lifts it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			alias := args[0]
+
+			var result model.Result
+			err := opts.updateConfig(func(managedConfig *config.ManagedConfig) error {
+				managedProject, found := projectManager.GetManagedProject(managedConfig, alias)
+				if !found {
+					return fmt.Errorf("no project found with alias '%s'", alias)
+				}
+
+				project := managedProject.Project
+				if cmd.Flags().Changed("name") {
+					project.Name = name
+				}
+				if cmd.Flags().Changed("path") {
+					if len(opts.Paths) != 1 {
+						return fmt.Errorf("only one --path can be set for a project")
+					}
+					path, err := filepath.Abs(opts.Paths[0])
+					if err != nil {
+						return fmt.Errorf("error resolving path: %w", err)
+					}
+					project.Path = path
+					// Keep the compose file name if the new directory has it too
+					if _, err := os.Stat(filepath.Join(path, project.File)); project.File == "" || err != nil {
+						project.File = config.FindComposeFile(path)
+					}
+				}
+				if cmd.Flags().Changed("file") {
+					project.File = file
+				}
+				if cmd.Flags().Changed("env-file") {
+					project.EnvFiles = nonEmpty(envFiles)
+				}
+				if cmd.Flags().Changed("compose-file") {
+					project.ComposeFiles = nonEmpty(composeFiles)
+				}
+				if cmd.Flags().Changed("profile") {
+					project.Profiles = nonEmpty(profiles)
+				}
+				if cmd.Flags().Changed("depends-on") {
+					project.DependsOn = nonEmpty(dependsOn)
+				}
+				if cmd.Flags().Changed("docker-context") && cmd.Flags().Changed("docker-host") {
+					return fmt.Errorf("--docker-context and --docker-host cannot be used together")
+				}
+				if cmd.Flags().Changed("docker-context") {
+					project.DockerContext, project.DockerHost = dockerContext, ""
+				}
+				if cmd.Flags().Changed("docker-host") {
+					project.DockerContext, project.DockerHost = "", dockerHost
+				}
+				if cmd.Flags().Changed("env") {
+					vars, err := parseEnv(nonEmpty(env))
+					if err != nil {
+						return err
+					}
+					project.Env = vars
+				}
+				if cmd.Flags().Changed("backend") {
+					if _, err := manager.ParseBackend(backend); err != nil {
+						return err
+					}
+					project.ComposeBackend = backend
+				}
+
+				if problem := config.CheckProjectFiles(project); problem != "" {
+					return fmt.Errorf("error updating managed project: %s", problem)
+				}
+
+				updated, err := projectManager.UpdateManagedProject(managedConfig, alias, project)
+				if err != nil {
+					return fmt.Errorf("error updating managed project: %w", err)
+				}
+				if cmd.Flags().Changed("protected") {
+					if updated, err = projectManager.SetManagedProjectProtected(managedConfig, alias, protected); err != nil {
+						return fmt.Errorf("error updating managed project: %w", err)
+					}
+				}
+
+				result = model.Result{
+					Project: updated.Project,
+					Success: true,
+					Message: fmt.Sprintf("Updated '%s'", alias),
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			printOutput(cmd, opts.Formatter.FormatActionResult(result))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Project name")
+	cmd.Flags().StringVar(&file, "file", "", "Compose file, relative to the project path")
+	cmd.Flags().StringSliceVar(&envFiles, "env-file", nil, "Env file passed to docker compose, can be repeated")
+	cmd.Flags().StringSliceVar(&composeFiles, "compose-file", nil, "Extra compose file, can be repeated")
+	cmd.Flags().StringSliceVar(&profiles, "profile", nil, "Compose profile to enable, can be repeated")
+	cmd.Flags().StringSliceVar(&dependsOn, "depends-on", nil, "Project started before this one, can be repeated")
+	cmd.Flags().StringVar(&dockerContext, "docker-context", "", "Docker context to run the project on")
+	cmd.Flags().StringVar(&dockerHost, "docker-host", "", "Docker host to run the project on, e.g. ssh://user@host")
+	cmd.Flags().StringArrayVar(&env, "env", nil, "Environment variable as KEY=value, can be repeated")
+	cmd.Flags().StringVar(&backend, "backend", "", "Compose engine of the project: "+strings.Join(manager.BackendNames, ", "))
+	cmd.Flags().BoolVar(&protected, "protected", false, "Require --force to stop or remove the project")
+
+	return cmd
+}
+
+// nonEmpty returns the non-empty values of a list flag, so an empty flag clears the list
+func nonEmpty(values []string) []string {
+	var kept []string
+	for _, value := range values {
+		if value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+// parseEnv parses KEY=value environment variables
+func parseEnv(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	env := make(map[string]string, len(values))
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid environment variable '%s', expected KEY=value", value)
+		}
+		env[key] = val
+	}
+	return env, nil
+}
+
+// newManagedExportCmd creates a command writing managed projects to a team manifest
+func newManagedExportCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	var (
+		root       string
+		file       string
+		gitRemotes bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export managed projects as a portable manifest",
+		Long: `Export the managed projects below a workspace root as a manifest with
+relative paths, which teammates can import with 'dcm managed import'.
+The root is --root, or the path of the workspace selected with --workspace,
+whose name is recorded in the manifest. Projects outside the root are left
+out with a warning.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspace := ""
+			switch len(opts.Workspaces) {
+			case 0:
+			case 1:
+				workspace = opts.Workspaces[0]
+			default:
+				return fmt.Errorf("only one workspace can be exported at a time")
+			}
+
+			if root == "" {
+				if workspace == "" {
+					return fmt.Errorf("workspace root is required, use --root or --workspace flag")
+				}
+				var err error
+				if root, err = opts.workspaceRoot(workspace); err != nil {
+					return err
+				}
+			}
+			if workspace == "" {
+				absRoot, err := filepath.Abs(root)
+				if err != nil {
+					return fmt.Errorf("error resolving root: %w", err)
+				}
+				workspace = filepath.Base(absRoot)
+			}
+
+			managedConfig, err := opts.loadConfig()
 			if err != nil {
 				return fmt.Errorf("error loading managed projects: %w", err)
 			}
 
-			// Remove the project from managed projects
-			if err := projectManager.RemoveManagedProject(managedConfig, alias); err != nil {
-				return fmt.Errorf("error removing managed project: %w", err)
+			manifest, warnings, err := projectManager.ExportManifest(managedConfig, root, workspace, gitRemotes)
+			if err != nil {
+				return err
+			}
+			for _, warning := range warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", warning)
+			}
+
+			data, err := config.MarshalManifest(manifest)
+			if err != nil {
+				return err
+			}
+
+			if file == "" {
+				_, err = cmd.OutOrStdout().Write(data)
+				return err
+			}
+			if opts.DryRun {
+				fmt.Fprintf(cmd.OutOrStdout(), "Would export %d project(s) to %s (dry run)\n", len(manifest.Projects), file)
+				return nil
+			}
+			if err := os.WriteFile(file, data, 0644); err != nil {
+				return fmt.Errorf("error writing manifest: %w", err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d project(s) to %s\n", len(manifest.Projects), file)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&root, "root", "", "Workspace root the exported paths are relative to")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Write the manifest to a file instead of stdout")
+	cmd.Flags().BoolVar(&gitRemotes, "git-remotes", false, "Record the git origin URL of each project")
+
+	return cmd
+}
+
+// newManagedImportCmd creates a command merging a team manifest into the managed projects
+func newManagedImportCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	var (
+		root     string
+		strategy string
+		force    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import [manifest]",
+		Short: "Import managed projects from a manifest",
+		Long: `Import the projects of a manifest written by 'dcm managed export'. Paths
+are resolved against --root, or against the path of the manifest's workspace
+in settings.workspaces, and every project must exist on disk.
+
+When an alias or path is already managed, --on-conflict decides:
+  skip       keep the managed project (default)
+  overwrite  take the path and compose file from the manifest, keeping
+             the settings of the managed project
+  rename     import under a free alias such as api-2
+
+Overwriting asks for confirmation first, pass --yes to skip it. Protected
+projects are only overwritten with --force.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conflictStrategy, err := manager.ParseConflictStrategy(strategy)
+			if err != nil {
+				return err
+			}
+
+			manifest, err := opts.store.LoadManifest(args[0])
+			if err != nil {
+				return err
+			}
+
+			// Without --root, the manifest's workspace must be defined locally
+			if root == "" {
+				if manifest.Workspace == "" {
+					return fmt.Errorf("workspace root is required, use --root flag")
+				}
+				if root, err = opts.workspaceRoot(manifest.Workspace); err != nil {
+					return fmt.Errorf("%w, or use --root flag", err)
+				}
+			}
+
+			if conflictStrategy == manager.ConflictOverwrite {
+				replaced, err := replacedProjects(projectManager, opts, manifest, root, force)
+				if err != nil {
+					return err
+				}
+				if len(replaced) > 0 {
+					if err := opts.confirm(cmd, "overwrite "+projectCount(replaced)+" in managed projects"); err != nil {
+						return err
+					}
+				}
 			}
 
-			// Save managed config
-			if err := config.SaveManagedConfig(managedConfig, configPath); err != nil {
-				return fmt.Errorf("error saving managed projects: %w", err)
+			var results []model.Result
+			err = opts.updateConfig(func(managedConfig *config.ManagedConfig) error {
+				results, err = projectManager.ImportManifest(managedConfig, manifest, root, conflictStrategy, force)
+				return err
+			})
+			if err != nil {
+				return err
 			}
 
-			fmt.Printf("%s✅ Project with alias '%s%s%s' removed from managed projects%s\n",
-				formatter.ColorGreen,
-				formatter.ColorBold, alias, formatter.ColorReset+formatter.ColorGreen,
-				formatter.ColorReset)
+			printOutput(cmd, opts.Formatter.FormatActionResults(results))
+
+			failed := 0
+			for _, result := range results {
+				if !result.Success {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d project(s) could not be imported", failed, len(results))
+			}
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&root, "root", "", "Workspace root the manifest paths are relative to")
+	cmd.Flags().StringVar(&strategy, "on-conflict", string(manager.ConflictSkip), "Conflict strategy: skip, overwrite or rename")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite protected projects too")
+
 	return cmd
 }
+
+// replacedProjects imports the manifest into a copy of the managed projects
+// and returns those the import would replace or remove
+func replacedProjects(projectManager *manager.Manager, opts *Options, manifest *model.Manifest, root string, force bool) ([]model.Project, error) {
+	managedConfig, err := opts.loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading managed projects: %w", err)
+	}
+	before := append([]model.ManagedProject(nil), managedConfig.Projects...)
+	if _, err := projectManager.ImportManifest(managedConfig, manifest, root, manager.ConflictOverwrite, force); err != nil {
+		return nil, err
+	}
+
+	var replaced []model.Project
+	for _, p := range before {
+		kept, found := projectManager.GetManagedProject(managedConfig, p.Alias)
+		if !found || !reflect.DeepEqual(kept, p) {
+			replaced = append(replaced, p.Project)
+		}
+	}
+	return replaced, nil
+}
+
+// checkManagedProject explains how to fix a managed project whose files are gone
+func checkManagedProject(managedProject model.ManagedProject) error {
+	if problem := config.CheckProjectFiles(managedProject.Project); problem != "" {
+		return fmt.Errorf("managed project '%s' is stale: %s (use 'dcm managed relocate %s --path DIR' or 'dcm managed prune')",
+			managedProject.Alias, problem, managedProject.Alias)
+	}
+	return nil
+}
+
+// findMovedProject searches each root path in turn for a moved project
+func findMovedProject(projectManager *manager.Manager, opts *Options, project model.Project) (model.Project, error) {
+	var err error
+	for _, path := range opts.Paths {
+		var moved model.Project
+		if moved, err = projectManager.FindMovedProject(project, path); err == nil {
+			return moved, nil
+		}
+	}
+	return model.Project{}, err
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// managedProjectConfig writes a project directory named api and a config
+// file managing it as api, returning the config path and the directory
+func managedProjectConfig(t *testing.T) (string, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "api")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte("services: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return isolate(t, strings.Replace(testConfig, "/srv/api", dir, 1)), dir
+}
+
+func TestManagedSetBackend(t *testing.T) {
+	configPath, _ := managedProjectConfig(t)
+
+	// The global flag selects the engine of this run, not the project's
+	if _, stderr, err := runDcm(t, strings.NewReader(""), "--compose-backend", "podman", "managed", "set", "api", "--profile", "dev"); err != nil {
+		t.Fatalf("%v: %s", err, stderr)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "compose_backend") {
+		t.Errorf("global --compose-backend was saved to the project:\n%s", data)
+	}
+
+	if _, stderr, err := runDcm(t, strings.NewReader(""), "managed", "set", "api", "--backend", "podman"); err != nil {
+		t.Fatalf("%v: %s", err, stderr)
+	}
+	if data, err = os.ReadFile(configPath); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "compose_backend: podman") {
+		t.Errorf("--backend was not saved to the project:\n%s", data)
+	}
+}
+
+func TestStopExactAlias(t *testing.T) {
+	managedProjectConfig(t)
+
+	// A part of the alias api names no managed project
+	if _, _, err := runDcm(t, strings.NewReader(""), "stop", "ap"); err == nil || !strings.Contains(err.Error(), "not found in managed projects") {
+		t.Errorf("got %v, want the project not found", err)
+	}
+}
@@ -0,0 +1,455 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mitas/dcm/internal/config"
+	"github.com/mitas/dcm/internal/manager"
+	"github.com/mitas/dcm/internal/model"
+	"github.com/mitas/dcm/pkg/formatter"
+)
+
+// Environment variables providing defaults for the global flags.
+// DCM_CONFIG is handled by config.GetDefaultConfigPath.
+const (
+	// envPath lists root paths separated by the OS path list separator
+	envPath = "DCM_PATH"
+	// envWorkspace lists workspace names separated by commas
+	envWorkspace   = "DCM_WORKSPACE"
+	envOutput      = "DCM_OUTPUT"
+	envColor       = "DCM_COLOR"
+	envParallelism = "DCM_PARALLELISM"
+	envTimeout     = "DCM_TIMEOUT"
+	envBackend     = "DCM_COMPOSE_BACKEND"
+	envStatus      = "DCM_STATUS_BACKEND"
+	envRetries     = "DCM_RETRIES"
+	envBackoff     = "DCM_RETRY_BACKOFF"
+)
+
+// Built-in defaults of settings without a flag default
+const (
+	defaultTimeout     = 5 * time.Minute
+	defaultParallelism = 0
+	defaultRetries     = 0
+	defaultBackoff     = 2 * time.Second
+)
+
+// Options holds the global settings of a dcm invocation. Each setting is
+// resolved with the precedence flag > DCM_* environment variable >
+// settings section of the config file > built-in default.
+type Options struct {
+	// ConfigPath is the config file given with --config, empty for the default
+	ConfigPath string
+	// Context selects the managed projects and settings to use, from
+	// --context, DCM_CONTEXT or the current context of the config file
+	Context string
+	// Paths are the root paths searched for projects
+	Paths []string
+	// PathsSet reports whether --path or --workspace was given on the
+	// command line. Managed projects are looked up first otherwise, even
+	// when DCM_PATH or DCM_WORKSPACE select the paths.
+	PathsSet bool
+	// Workspaces are the names of the selected workspaces
+	Workspaces []string
+	// Parallelism limits how many projects bulk actions handle at once
+	Parallelism int
+	// Timeout bounds start and stop actions, including retries and waiting
+	Timeout time.Duration
+	// Output is the output format
+	Output string
+	// Color is the color mode: auto, always or never
+	Color string
+	// ComposeBackend is the compose engine of projects without their own
+	ComposeBackend string
+	// StatusBackend is how status is checked: compose or engine
+	StatusBackend string
+	// Retries is how many times start and stop retry transient failures
+	Retries int
+	// RetryBackoff is the wait before the first retry, doubled after each
+	RetryBackoff time.Duration
+	// DryRun prints the commands that change state instead of running them
+	DryRun bool
+	// Yes answers yes to confirmation prompts
+	Yes bool
+	// Format configures the formatters
+	Format formatter.Options
+	// Formatter renders output, selected once the options are resolved
+	Formatter formatter.Formatter
+
+	// paths and workspaces are the raw values of --path and --workspace
+	paths      []string
+	workspaces []string
+	// rootWorkspaces maps root paths to the workspace they were selected from
+	rootWorkspaces map[string]string
+	// settings is the settings section of the config file
+	settings *config.Settings
+	// store reads and writes the config files, warning on stderr
+	store *config.Store
+}
+
+// bindFlags registers the global flags on the root command
+func (o *Options) bindFlags(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.StringArrayVarP(&o.paths, "path", "p", nil, "Root path to search for docker-compose projects, can be repeated (default $DCM_PATH or settings.paths)")
+	flags.StringArrayVarP(&o.workspaces, "workspace", "w", nil, "Named workspace from settings.workspaces to search, can be repeated (default $DCM_WORKSPACE)")
+	flags.StringVar(&o.Context, "context", "", "Context of managed projects and settings to use (default $DCM_CONTEXT or the current context)")
+	flags.StringVarP(&o.ConfigPath, "config", "c", "", "Path to config file (default is $DCM_CONFIG, $XDG_CONFIG_HOME/dcm/config.yaml or ~/.config/dcm/config.yaml)")
+	flags.StringVarP(&o.Output, "output", "o", formatter.FormatText, "Output format: text, json, yaml or table")
+	flags.StringSliceVar(&o.Format.Columns, "columns", nil, "Columns to show with --output table: "+strings.Join(formatter.TableColumns, ","))
+	flags.StringVar(&o.Format.Sort, "sort", "", "Column to sort --output table rows by")
+	flags.BoolVar(&o.Format.NoHeaders, "no-headers", false, "Hide the header row with --output table")
+	flags.StringVar(&o.Color, "color", formatter.ColorAuto, "When to use colors: auto, always or never")
+	flags.BoolVar(&o.Format.NoEmoji, "no-emoji", false, "Disable emojis in text output")
+	flags.IntVar(&o.Parallelism, "parallelism", defaultParallelism, "Maximum number of projects handled at once by bulk actions, 0 for no limit")
+	flags.DurationVar(&o.Timeout, "timeout", defaultTimeout, "Timeout for start and stop, including retries and --wait")
+	flags.StringVar(&o.ComposeBackend, "compose-backend", manager.BackendAuto, "Compose engine: auto, "+strings.Join(manager.BackendNames, ", "))
+	flags.BoolVar(&o.DryRun, "dry-run", false, "Print the commands that would change state, such as docker compose up and down and hooks, without running them")
+	flags.BoolVarP(&o.Yes, "yes", "y", false, "Confirm bulk and destructive actions, such as stop --all, without asking")
+	flags.StringVar(&o.StatusBackend, "status-backend", manager.StatusCompose, "How status is checked: compose, or engine to query the Docker Engine API socket")
+}
+
+// bindRetryFlags registers the retry flags on a command running compose up or down
+func (o *Options) bindRetryFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&o.Retries, "retries", defaultRetries, "Number of times to retry transient failures such as ports in use or registry errors")
+	cmd.Flags().DurationVar(&o.RetryBackoff, "retry-backoff", defaultBackoff, "Wait before the first retry, doubled after each retry with random jitter")
+}
+
+// resolve fills every setting not given as a flag from the environment,
+// then from the config file, then from the built-in defaults, and selects
+// the formatter
+func (o *Options) resolve(cmd *cobra.Command, projectManager *manager.Manager) error {
+	managedConfig, theme, err := o.readConfig(cmd)
+	if err != nil {
+		return err
+	}
+	return o.apply(cmd, projectManager, managedConfig.Settings, theme)
+}
+
+// resolveRecovering is resolve for the commands repairing the config file:
+// when the file cannot be loaded, the built-in defaults are used instead so
+// a broken config does not lock the user out of fixing it
+func (o *Options) resolveRecovering(cmd *cobra.Command, projectManager *manager.Manager) error {
+	managedConfig, theme, err := o.readConfig(cmd)
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v, using the default settings\n", err)
+		return o.apply(cmd, projectManager, nil, formatter.DefaultTheme)
+	}
+	return o.apply(cmd, projectManager, managedConfig.Settings, theme)
+}
+
+// readConfig selects the context and loads its managed projects, settings
+// and theme from the config file
+func (o *Options) readConfig(cmd *cobra.Command) (*config.ManagedConfig, formatter.Theme, error) {
+	o.store = config.NewStore(cmd.ErrOrStderr())
+	rootConfig, err := o.store.LoadManagedConfig(o.ConfigPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading config: %w", err)
+	}
+	if !cmd.Flags().Changed("context") {
+		o.Context = os.Getenv(config.ContextEnv)
+		if o.Context == "" {
+			o.Context = rootConfig.CurrentContext
+			// A context removed by hand must not lock the user out of dcm
+			if _, ok := rootConfig.Contexts[o.Context]; o.Context != "" && !ok {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: current context '%s' does not exist, using the default context\n", o.Context)
+				o.Context = ""
+			}
+		}
+	}
+
+	managedConfig, err := o.store.LoadContextConfig(o.ConfigPath, o.Context)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading config: %w", err)
+	}
+
+	theme, err := buildTheme(managedConfig.Theme)
+	if err != nil {
+		return nil, nil, err
+	}
+	return managedConfig, theme, nil
+}
+
+// apply resolves the settings from the flags, the environment and settings,
+// which may be nil, and configures the manager and the formatter
+func (o *Options) apply(cmd *cobra.Command, projectManager *manager.Manager, settings *config.Settings, theme formatter.Theme) error {
+	var err error
+	if settings == nil {
+		settings = &config.Settings{}
+	}
+	o.settings = settings
+	changed := cmd.Flags().Changed
+
+	if err := o.resolvePaths(changed("path") || changed("workspace")); err != nil {
+		return err
+	}
+
+	if !changed("output") {
+		o.Output = firstNonEmpty(os.Getenv(envOutput), settings.Output, o.Output)
+	}
+	if !changed("color") {
+		o.Color = firstNonEmpty(os.Getenv(envColor), settings.Color, o.Color)
+	}
+
+	if !changed("parallelism") {
+		if value := os.Getenv(envParallelism); value != "" {
+			if o.Parallelism, err = strconv.Atoi(value); err != nil {
+				return fmt.Errorf("invalid %s '%s': %w", envParallelism, value, err)
+			}
+		} else if settings.Parallelism != 0 {
+			o.Parallelism = settings.Parallelism
+		}
+	}
+	if o.Parallelism < 0 {
+		return fmt.Errorf("parallelism must not be negative")
+	}
+
+	if !changed("timeout") {
+		if value := os.Getenv(envTimeout); value != "" {
+			if o.Timeout, err = time.ParseDuration(value); err != nil {
+				return fmt.Errorf("invalid %s '%s': %w", envTimeout, value, err)
+			}
+		} else if settings.Timeout != 0 {
+			o.Timeout = settings.Timeout
+		}
+	}
+
+	if !changed("compose-backend") {
+		o.ComposeBackend = firstNonEmpty(os.Getenv(envBackend), settings.ComposeBackend, o.ComposeBackend)
+	}
+	backend, err := manager.ParseBackend(o.ComposeBackend)
+	if err != nil {
+		return err
+	}
+
+	if !changed("status-backend") {
+		o.StatusBackend = firstNonEmpty(os.Getenv(envStatus), settings.StatusBackend, o.StatusBackend)
+	}
+	switch o.StatusBackend {
+	case manager.StatusCompose:
+		projectManager.SetEngineClient(nil)
+	case manager.StatusEngine:
+		projectManager.SetEngineClient(manager.NewEngineClient(""))
+	default:
+		return fmt.Errorf("unknown status backend '%s' (valid backends: %s, %s)", o.StatusBackend, manager.StatusCompose, manager.StatusEngine)
+	}
+
+	if !changed("retries") {
+		o.Retries = defaultRetries
+		if value := os.Getenv(envRetries); value != "" {
+			if o.Retries, err = strconv.Atoi(value); err != nil {
+				return fmt.Errorf("invalid %s '%s': %w", envRetries, value, err)
+			}
+		} else if settings.Retries != 0 {
+			o.Retries = settings.Retries
+		}
+	}
+	if o.Retries < 0 {
+		return fmt.Errorf("retries must not be negative")
+	}
+
+	if !changed("retry-backoff") {
+		o.RetryBackoff = defaultBackoff
+		if value := os.Getenv(envBackoff); value != "" {
+			if o.RetryBackoff, err = time.ParseDuration(value); err != nil {
+				return fmt.Errorf("invalid %s '%s': %w", envBackoff, value, err)
+			}
+		} else if settings.RetryBackoff != 0 {
+			o.RetryBackoff = settings.RetryBackoff
+		}
+	}
+
+	projectManager.SetStore(o.store)
+	projectManager.SetParallelism(o.Parallelism)
+	projectManager.SetRetry(o.Retries, o.RetryBackoff)
+	if o.DryRun {
+		// One project at a time keeps the printed plan in order
+		projectManager.SetParallelism(1)
+		projectManager.SetDryRun(cmd.OutOrStdout())
+		o.store.SetDryRun(cmd.OutOrStdout())
+	}
+	projectManager.SetBackend(backend)
+
+	useColor, err := formatter.UseColor(o.Color, cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+	o.Format.NoColor = !useColor
+
+	o.Format.Theme = theme
+
+	o.Formatter, err = formatter.New(o.Output, o.Format)
+	return err
+}
+
+// actionContext bounds a start or stop, with its retries and waiting, by
+// --timeout. It is also cancelled when dcm is interrupted.
+func (o *Options) actionContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(cmd.Context(), o.Timeout)
+}
+
+// loadConfig loads the managed projects and settings of the selected context
+func (o *Options) loadConfig() (*config.ManagedConfig, error) {
+	return o.store.LoadContextConfig(o.ConfigPath, o.Context)
+}
+
+// updateConfig changes the managed projects of the selected context while
+// holding the lock of the file storing them
+func (o *Options) updateConfig(update func(managedConfig *config.ManagedConfig) error) error {
+	return o.store.UpdateContextConfig(o.ConfigPath, o.Context, update)
+}
+
+// resolvePaths selects the root paths: --path and --workspace when either
+// flag is given, then DCM_PATH and DCM_WORKSPACE, then settings.paths
+func (o *Options) resolvePaths(flagsSet bool) error {
+	paths, workspaces := o.paths, o.workspaces
+	if !flagsSet {
+		paths = filepath.SplitList(os.Getenv(envPath))
+		workspaces = nil
+		for _, name := range strings.Split(os.Getenv(envWorkspace), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				workspaces = append(workspaces, name)
+			}
+		}
+	}
+
+	o.Paths = nil
+	o.Workspaces = workspaces
+	o.rootWorkspaces = make(map[string]string)
+	o.PathsSet = flagsSet
+	if len(paths) == 0 && len(workspaces) == 0 {
+		o.Paths = expandHome(o.settings.Paths)
+		return nil
+	}
+
+	o.Paths = append(o.Paths, paths...)
+	for _, name := range workspaces {
+		roots, err := o.workspacePaths(name)
+		if err != nil {
+			return err
+		}
+		for _, root := range roots {
+			o.Paths = append(o.Paths, root)
+			o.rootWorkspaces[root] = name
+		}
+	}
+	return nil
+}
+
+// workspacePaths returns the root paths of a named workspace
+func (o *Options) workspacePaths(name string) ([]string, error) {
+	roots, ok := o.settings.Workspaces[name]
+	if !ok {
+		names := make([]string, 0, len(o.settings.Workspaces))
+		for workspace := range o.settings.Workspaces {
+			names = append(names, workspace)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown workspace '%s', define it under settings.workspaces in the config file", name)
+		}
+		return nil, fmt.Errorf("unknown workspace '%s' (defined workspaces: %s)", name, strings.Join(names, ", "))
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("workspace '%s' has no paths", name)
+	}
+	return expandHome(roots), nil
+}
+
+// workspaceRoot returns the single root path of a named workspace
+func (o *Options) workspaceRoot(name string) (string, error) {
+	roots, err := o.workspacePaths(name)
+	if err != nil {
+		return "", err
+	}
+	if len(roots) != 1 {
+		return "", fmt.Errorf("workspace '%s' has %d paths, use --root to choose one", name, len(roots))
+	}
+	return roots[0], nil
+}
+
+// firstNonEmpty returns the first value that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// expandHome replaces a leading ~ in paths with the home directory
+func expandHome(paths []string) []string {
+	home, err := os.UserHomeDir()
+	expanded := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if err == nil && (path == "~" || strings.HasPrefix(path, "~/")) {
+			path = filepath.Join(home, path[1:])
+		}
+		expanded = append(expanded, path)
+	}
+	return expanded
+}
+
+// commandFormatter returns a template formatter when --format is given,
+// otherwise the formatter selected by --output
+func (o *Options) commandFormatter(format string) (formatter.Formatter, error) {
+	if format == "" {
+		return o.Formatter, nil
+	}
+	return formatter.NewTemplateFormatter(format, o.Format)
+}
+
+// resolvedConfigPath returns the config file in use, from --config or the defaults
+func (o *Options) resolvedConfigPath() string {
+	if o.ConfigPath != "" {
+		return o.ConfigPath
+	}
+	return config.GetDefaultConfigPath()
+}
+
+// pathsDescription names the root paths in messages
+func (o *Options) pathsDescription() string {
+	return strings.Join(o.Paths, ", ")
+}
+
+// findProjects searches every root path for docker-compose projects.
+// A project reachable from several roots, directly or through symlinks,
+// is only listed once, with the workspace of the first root.
+func (o *Options) findProjects(projectManager *manager.Manager) ([]model.Project, error) {
+	var projects []model.Project
+	seen := make(map[string]bool)
+
+	for _, root := range o.Paths {
+		found, err := projectManager.FindProjects(root)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, project := range found {
+			realPath, err := filepath.EvalSymlinks(project.Path)
+			if err != nil {
+				realPath = project.Path
+			}
+			if absPath, err := filepath.Abs(realPath); err == nil {
+				realPath = absPath
+			}
+			if seen[realPath] {
+				continue
+			}
+			seen[realPath] = true
+
+			project.Workspace = o.rootWorkspaces[root]
+			projects = append(projects, project)
+		}
+	}
+	return projects, nil
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mitas/dcm/internal/manager"
+	"github.com/mitas/dcm/internal/model"
+)
+
+// newPsCmd creates a command listing the compose projects known to the engine
+func newPsCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
+	var orphans bool
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "ps",
+		Short: "List compose projects known to the engine, including orphans",
+		Long: `List every compose project known to the container engine, including
+projects started from directories dcm does not know or that were deleted.
+Each one is matched to a managed project or a project under the root paths
+by its working directory; projects without a match are orphans and can be
+stopped with 'dcm stop --orphan <name>'.
+
+Projects are listed with 'docker compose ls', or with a single Docker Engine
+API request when --status-backend engine is used.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := opts.commandFormatter(format)
+			if err != nil {
+				return err
+			}
+
+			projects, err := engineProjects(projectManager, opts)
+			if err != nil {
+				return err
+			}
+
+			if orphans {
+				kept := projects[:0]
+				for _, p := range projects {
+					if p.Orphan() {
+						kept = append(kept, p)
+					}
+				}
+				projects = kept
+			}
+
+			printOutput(cmd, out.FormatEngineProjects(projects))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&orphans, "orphans", false, "Only list projects that match no managed or discovered project")
+	cmd.Flags().StringVar(&format, "format", "", "Format output using a Go template, e.g. '{{.Name}}\t{{.WorkingDir}}'")
+
+	return cmd
+}
+
+// engineProjects lists the compose projects known to the engine, matched
+// to the managed projects and the projects under the root paths
+func engineProjects(projectManager *manager.Manager, opts *Options) ([]model.EngineProject, error) {
+	managedConfig, err := opts.loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading managed projects: %w", err)
+	}
+
+	var projects []model.Project
+	if len(opts.Paths) > 0 {
+		if projects, err = opts.findProjects(projectManager); err != nil {
+			return nil, fmt.Errorf("error finding projects: %w", err)
+		}
+	}
+
+	listed, err := projectManager.ListEngineProjects()
+	if err != nil {
+		return nil, err
+	}
+	return projectManager.CorrelateEngineProjects(listed, managedConfig.Projects, projects), nil
+}
+
+// stopOrphan stops a compose project that matches no managed or discovered project
+func stopOrphan(cmd *cobra.Command, projectManager *manager.Manager, opts *Options, name string) error {
+	projects, err := engineProjects(projectManager, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range projects {
+		if p.Name != name {
+			continue
+		}
+		if !p.Orphan() {
+			owner := p.Alias
+			if owner == "" {
+				owner = p.Project.Name
+			}
+			return fmt.Errorf("compose project '%s' belongs to %s, stop it with: dcm stop %s", name, owner, owner)
+		}
+
+		if err := opts.confirm(cmd, "stop orphan compose project "+name); err != nil {
+			return err
+		}
+
+		printOutput(cmd, opts.Formatter.FormatActionStart("Stopping orphan", name))
+		result := projectManager.StopEngineProject(p)
+		printOutput(cmd, opts.Formatter.FormatActionResult(result))
+		return nil
+	}
+
+	return fmt.Errorf("no compose project named '%s' is known to the engine, list them with: dcm ps", name)
+}
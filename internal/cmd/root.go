@@ -1,19 +1,17 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
 	"github.com/mitas/dcm/internal/manager"
-	"github.com/mitas/dcm/pkg/formatter"
-)
-
-var (
-	rootPath   string
-	configPath string
 )
 
 // NewRootCmd creates the root command for the application
-func NewRootCmd(projectManager *manager.Manager, outputFormatter *formatter.Formatter) *cobra.Command {
+func NewRootCmd(projectManager *manager.Manager) *cobra.Command {
+	opts := &Options{}
+
 	rootCmd := &cobra.Command{
 		Use:   "dcm",
 		Short: "Docker Compose Manager - Manage multiple docker-compose projects",
@@ -22,23 +20,41 @@ multiple docker-compose projects in a directory structure.
 
 It allows you to list, start, stop, and check the status of docker-compose 
 projects in a given directory.`,
-		SilenceUsage: true,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.resolve(cmd, projectManager)
+		},
 	}
 
 	// Global flags
-	rootCmd.PersistentFlags().StringVarP(&rootPath, "path", "p", "", "Root path to search for docker-compose projects")
-	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Path to config file (default is ~/.config/dcm/config.yaml)")
+	opts.bindFlags(rootCmd)
 
 	// Add subcommands
-	rootCmd.AddCommand(newListCmd(projectManager, outputFormatter))
-	rootCmd.AddCommand(newStartCmd(projectManager, outputFormatter))
-	rootCmd.AddCommand(newStopCmd(projectManager, outputFormatter))
-	rootCmd.AddCommand(newStatusCmd(projectManager, outputFormatter))
+	rootCmd.AddCommand(newListCmd(projectManager, opts))
+	rootCmd.AddCommand(newStartCmd(projectManager, opts))
+	rootCmd.AddCommand(newStopCmd(projectManager, opts))
+	rootCmd.AddCommand(newStatusCmd(projectManager, opts))
+	rootCmd.AddCommand(newPsCmd(projectManager, opts))
 
 	// Add managed project commands
-	rootCmd.AddCommand(newListManagedCmd(projectManager, outputFormatter))
-	rootCmd.AddCommand(newAddManagedCmd(projectManager, outputFormatter))
-	rootCmd.AddCommand(newRemoveManagedCmd(projectManager, outputFormatter))
+	rootCmd.AddCommand(newListManagedCmd(projectManager, opts))
+	rootCmd.AddCommand(newAddManagedCmd(projectManager, opts))
+	rootCmd.AddCommand(newRemoveManagedCmd(projectManager, opts))
+	rootCmd.AddCommand(newManagedCmd(projectManager, opts))
+	rootCmd.AddCommand(newInspectCmd(projectManager, opts))
+
+	// Add config file commands
+	rootCmd.AddCommand(newConfigCmd(projectManager, opts))
+	rootCmd.AddCommand(newContextCmd(opts))
 
 	return rootCmd
 }
+
+// printOutput prints formatted output to the command's output writer,
+// skipping formats that produce nothing
+func printOutput(cmd *cobra.Command, output string) {
+	if output != "" {
+		fmt.Fprintln(cmd.OutOrStdout(), output)
+	}
+}
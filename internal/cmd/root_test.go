@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitas/dcm/internal/manager"
+)
+
+// testConfig is a config file with a single managed project
+const testConfig = `version: 1
+projects:
+  - alias: api
+    project:
+      name: api
+      path: /srv/api
+      file: docker-compose.yml
+`
+
+// isolate points dcm at a config file written from content and clears the
+// environment variables changing its behaviour
+func isolate(t *testing.T, content string) string {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DCM_CONFIG", configPath)
+	for _, name := range []string{"DCM_PATH", "DCM_WORKSPACE", "DCM_CONTEXT", "DCM_OUTPUT", "DCM_COLOR", "NO_COLOR", "CLICOLOR_FORCE", "TERM"} {
+		t.Setenv(name, "")
+	}
+	// No compose engine is ever run
+	t.Setenv("PATH", t.TempDir())
+	return configPath
+}
+
+// runDcm runs dcm with args, reading stdin from in, and returns what it
+// wrote to stdout and stderr
+func runDcm(t *testing.T, in io.Reader, args ...string) (string, string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	root := NewRootCmd(manager.NewManager(nil))
+	root.SetIn(in)
+	root.SetOut(&stdout)
+	root.SetErr(&stderr)
+	root.SetArgs(args)
+	err := root.Execute()
+	return stdout.String(), stderr.String(), err
+}
+
+func TestColor(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		args  []string
+		color bool
+	}{
+		{"not a terminal", nil, nil, false},
+		{"always", nil, []string{"--color", "always"}, true},
+		{"never", map[string]string{"CLICOLOR_FORCE": "1"}, []string{"--color", "never"}, false},
+		{"NO_COLOR", map[string]string{"NO_COLOR": "1", "CLICOLOR_FORCE": "1"}, nil, false},
+		{"CLICOLOR_FORCE", map[string]string{"CLICOLOR_FORCE": "1"}, nil, true},
+		{"DCM_COLOR", map[string]string{"DCM_COLOR": "always"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t, testConfig)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			stdout, stderr, err := runDcm(t, strings.NewReader(""), append(tt.args, "list-managed")...)
+			if err != nil {
+				t.Fatalf("%v: %s", err, stderr)
+			}
+			if !strings.Contains(stdout, "api") {
+				t.Fatalf("output has no project:\n%s", stdout)
+			}
+			if got := strings.Contains(stdout, "\x1b["); got != tt.color {
+				t.Errorf("colored is %v, want %v:\n%q", got, tt.color, stdout)
+			}
+		})
+	}
+}
+
+func TestNoEmoji(t *testing.T) {
+	isolate(t, testConfig)
+
+	stdout, stderr, err := runDcm(t, strings.NewReader(""), "--no-emoji", "list-managed")
+	if err != nil {
+		t.Fatalf("%v: %s", err, stderr)
+	}
+	for _, r := range stdout {
+		if r > 0x2000 {
+			t.Fatalf("output has %q with --no-emoji:\n%s", r, stdout)
+		}
+	}
+}
+
+func TestInvalidColorMode(t *testing.T) {
+	isolate(t, testConfig)
+
+	if _, _, err := runDcm(t, strings.NewReader(""), "--color", "sometimes", "list-managed"); err == nil || !strings.Contains(err.Error(), "unknown color mode") {
+		t.Errorf("got %v, want an unknown color mode error", err)
+	}
+}
+
+func TestConfigWarningsOnStderr(t *testing.T) {
+	isolate(t, testConfig+"colour: always\n")
+
+	stdout, stderr, err := runDcm(t, strings.NewReader(""), "-o", "json", "list-managed")
+	if err != nil {
+		t.Fatalf("%v: %s", err, stderr)
+	}
+	if !strings.HasPrefix(stdout, "[") {
+		t.Errorf("stdout is not only JSON:\n%s", stdout)
+	}
+	// The config is loaded several times, the warning is written once
+	if strings.Count(stderr, "Warning:") != 1 || !strings.Contains(stderr, "colour") {
+		t.Errorf("stderr is %q, want one warning about the unknown field", stderr)
+	}
+}
@@ -1,85 +1,101 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/mitas/dcm/internal/config"
 	"github.com/mitas/dcm/internal/manager"
 	"github.com/mitas/dcm/internal/model"
-	"github.com/mitas/dcm/pkg/formatter"
 )
 
+// defaultWaitTimeout bounds waiting for services with --wait
+const defaultWaitTimeout = 2 * time.Minute
+
 // newStartCmd creates the start command
-func newStartCmd(projectManager *manager.Manager, outputFormatter *formatter.Formatter) *cobra.Command {
+func newStartCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
 	var all bool
 	var projectName string
+	var wait bool
+	var waitTimeout time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "start [project]",
 		Short: "Start docker-compose projects",
-		Long:  `Start one or all docker-compose projects in the specified path or from managed projects.`,
+		Long: `Start one or all docker-compose projects in the specified path or from managed projects.
+
+With --wait, each project is only reported as started once every service is
+running and healthy, or has exited successfully for one-shot services.
+Dependencies are waited for before their dependents start. A service that
+becomes unhealthy, exits with an error or is not ready within --wait-timeout
+fails the start, and its last log lines are shown.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if wait || cmd.Flags().Changed("wait-timeout") {
+				if waitTimeout <= 0 {
+					return fmt.Errorf("--wait-timeout must be positive")
+				}
+				projectManager.SetWait(waitTimeout)
+			}
+
 			// If no project name provided directly, check args
 			if projectName == "" && len(args) > 0 {
 				projectName = args[0]
 			}
 
 			// Check if it's a managed project first
-			if projectName != "" && rootPath == "" {
+			if projectName != "" && !opts.PathsSet {
 				// Load managed config
-				managedConfig, err := config.LoadManagedConfig(configPath)
+				managedConfig, err := opts.loadConfig()
 				if err != nil {
 					return fmt.Errorf("error loading managed projects: %w", err)
 				}
 
 				// Try to find it in managed projects
-				managedProject, found := projectManager.FindManagedProject(managedConfig, projectName)
+				managedProject, found := projectManager.GetManagedProject(managedConfig, projectName)
 				if found {
-					fmt.Println(outputFormatter.FormatActionStart("Starting managed", managedProject.Alias))
-					result := projectManager.StartProject(managedProject.Project)
-					fmt.Println(outputFormatter.FormatActionResult(result))
+					if err := checkManagedProject(managedProject); err != nil {
+						return err
+					}
+					printOutput(cmd, opts.Formatter.FormatActionStart("Starting managed", managedProject.Alias))
+					ctx, cancel := opts.actionContext(cmd)
+					defer cancel()
+					results := projectManager.StartProjectWithDependencies(ctx, managedProject.Project, projectManager.ManagedProjectLookup(managedConfig))
+					printStartResults(cmd, opts, results)
 					return nil
 				}
 
 				// Project not found in managed projects
-				if rootPath == "" {
+				if len(opts.Paths) == 0 {
 					return fmt.Errorf("project '%s' not found in managed projects and no --path provided", projectName)
 				}
 			}
 
-			// If we reach here, we need rootPath
-			if rootPath == "" {
+			// If we reach here, we need a root path
+			if len(opts.Paths) == 0 {
 				return fmt.Errorf("path is required to find projects, use --path flag")
 			}
 
 			// Find all docker-compose projects
-			projects, err := projectManager.FindProjects(rootPath)
+			projects, err := opts.findProjects(projectManager)
 			if err != nil {
 				return fmt.Errorf("error finding projects: %w", err)
 			}
 
 			if len(projects) == 0 {
-				fmt.Println(outputFormatter.FormatNoProjectsFound())
+				printOutput(cmd, opts.Formatter.FormatNoProjectsFound())
 				return nil
 			}
 
-			// Set a timeout for the operation
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			ctx, cancel := opts.actionContext(cmd)
 			defer cancel()
 
 			if all {
 				// Start all projects
-				fmt.Printf("%s🔄 Starting %s%d%s Docker Compose projects...%s\n",
-					formatter.ColorBold, formatter.ColorGreen, len(projects), formatter.ColorReset, formatter.ColorReset)
+				printOutput(cmd, opts.Formatter.FormatBulkActionStart("Starting", len(projects)))
 
 				results := projectManager.ManageAllProjects(ctx, projects, model.ActionStart)
-				for _, result := range results {
-					fmt.Println(outputFormatter.FormatActionResult(result))
-				}
+				printOutput(cmd, opts.Formatter.FormatActionResults(results))
 				return nil
 			}
 
@@ -96,13 +112,13 @@ func newStartCmd(projectManager *manager.Manager, outputFormatter *formatter.For
 			// Find and start the specific project
 			project, found := projectManager.FindProject(projects, projectName)
 			if !found {
-				fmt.Println(outputFormatter.FormatProjectNotFound(projectName))
+				printOutput(cmd, opts.Formatter.FormatProjectNotFound(projectName))
 				return nil
 			}
 
-			fmt.Println(outputFormatter.FormatActionStart("Starting", project.Name))
-			result := projectManager.StartProject(project)
-			fmt.Println(outputFormatter.FormatActionResult(result))
+			printOutput(cmd, opts.Formatter.FormatActionStart("Starting", project.Name))
+			results := projectManager.StartProjectWithDependencies(ctx, project, projectManager.ProjectLookup(projects))
+			printStartResults(cmd, opts, results)
 			return nil
 		},
 	}
@@ -110,6 +126,20 @@ func newStartCmd(projectManager *manager.Manager, outputFormatter *formatter.For
 	// Add flags
 	cmd.Flags().BoolVarP(&all, "all", "a", false, "Start all docker-compose projects")
 	cmd.Flags().StringVarP(&projectName, "project", "n", "", "Name of the project to start")
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait until services are running and healthy, or completed for one-shot services")
+	cmd.Flags().DurationVar(&waitTimeout, "wait-timeout", defaultWaitTimeout, "Maximum time to wait for services with --wait")
+
+	opts.bindRetryFlags(cmd)
 
 	return cmd
 }
+
+// printStartResults prints the result of starting a project, preceded by
+// the results of the dependencies started for it
+func printStartResults(cmd *cobra.Command, opts *Options, results []model.Result) {
+	if len(results) == 1 {
+		printOutput(cmd, opts.Formatter.FormatActionResult(results[0]))
+		return
+	}
+	printOutput(cmd, opts.Formatter.FormatActionResults(results))
+}
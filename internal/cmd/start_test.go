@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDocker makes script the only docker on PATH
+func fakeDocker(t *testing.T, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+// portInUse answers version and fails every other command with a
+// transient error
+const portInUse = `case " $* " in *" version "*) exit 0 ;; esac
+echo 'Bind for 0.0.0.0:80 failed: port is already allocated'
+exit 1
+`
+
+func TestTimeoutBoundsEveryAction(t *testing.T) {
+	// Managed projects, discovered projects and bulk actions
+	tests := []struct {
+		args     []string
+		discover bool
+	}{
+		{[]string{"start", "api"}, false},
+		{[]string{"stop", "api"}, false},
+		{[]string{"start", "api"}, true},
+		{[]string{"stop", "api"}, true},
+		{[]string{"start", "--all"}, true},
+		{[]string{"stop", "--all"}, true},
+	}
+
+	for _, tt := range tests {
+		name := strings.Join(tt.args, " ")
+		if tt.discover {
+			name += " --path"
+		}
+		t.Run(name, func(t *testing.T) {
+			_, dir := managedProjectConfig(t)
+			fakeDocker(t, portInUse)
+
+			args := []string{"--yes", "--timeout", "100ms", "--compose-backend", "docker"}
+			if tt.discover {
+				args = append(args, "--path", filepath.Dir(dir))
+			}
+			args = append(append(args, tt.args...), "--retries", "5", "--retry-backoff", "1m")
+
+			begin := time.Now()
+			stdout, stderr, err := runDcm(t, strings.NewReader(""), args...)
+			if elapsed := time.Since(begin); elapsed > 5*time.Second {
+				t.Errorf("gave up after %s, want right after --timeout", elapsed)
+			}
+			if err != nil {
+				t.Fatalf("%v: %s", err, stderr)
+			}
+			if !strings.Contains(stdout, "not retried") {
+				t.Errorf("output is:\n%s\nwant the retry cut short", stdout)
+			}
+		})
+	}
+}
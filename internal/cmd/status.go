@@ -5,82 +5,81 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/mitas/dcm/internal/config"
 	"github.com/mitas/dcm/internal/manager"
-	"github.com/mitas/dcm/pkg/formatter"
 )
 
 // newStatusCmd creates the status command
-func newStatusCmd(projectManager *manager.Manager, outputFormatter *formatter.Formatter) *cobra.Command {
+func newStatusCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
 	var all bool
 	var projectName string
+	var format string
 
 	cmd := &cobra.Command{
 		Use:   "status [project]",
 		Short: "Check status of docker-compose projects",
 		Long:  `Check the status of one or all docker-compose projects in the specified path or from managed projects.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := opts.commandFormatter(format)
+			if err != nil {
+				return err
+			}
+
 			// If no project name provided directly, check args
 			if projectName == "" && len(args) > 0 {
 				projectName = args[0]
 			}
 
 			// Check if it's a managed project first
-			if projectName != "" && rootPath == "" {
+			if projectName != "" && !opts.PathsSet {
 				// Load managed config
-				managedConfig, err := config.LoadManagedConfig(configPath)
+				managedConfig, err := opts.loadConfig()
 				if err != nil {
 					return fmt.Errorf("error loading managed projects: %w", err)
 				}
 
 				// Try to find it in managed projects
-				managedProject, found := projectManager.FindManagedProject(managedConfig, projectName)
+				managedProject, found := projectManager.GetManagedProject(managedConfig, projectName)
 				if found {
-					fmt.Println(outputFormatter.FormatActionStart("Checking status of managed", managedProject.Alias))
-					isRunning, services, err := projectManager.CheckProjectStatus(managedProject.Project)
-					if err != nil {
-						return fmt.Errorf("error checking status of %s: %w", managedProject.Alias, err)
+					if err := checkManagedProject(managedProject); err != nil {
+						return err
+					}
+					printOutput(cmd, out.FormatActionStart("Checking status of managed", managedProject.Alias))
+					status := projectManager.GetProjectStatus(managedProject.Project)
+					if status.Error != nil {
+						return fmt.Errorf("error checking status of %s: %w", managedProject.Alias, status.Error)
 					}
-					fmt.Println(outputFormatter.FormatProjectStatus(managedProject.Project.Name, managedProject.Project.Path, isRunning, services))
+					printOutput(cmd, out.FormatProjectStatus(status))
 					return nil
 				}
 
 				// Project not found in managed projects
-				if rootPath == "" {
+				if len(opts.Paths) == 0 {
 					return fmt.Errorf("project '%s' not found in managed projects and no --path provided", projectName)
 				}
 			}
 
-			// If we reach here, we need rootPath
-			if rootPath == "" {
+			// If we reach here, we need a root path
+			if len(opts.Paths) == 0 {
 				return fmt.Errorf("path is required to find projects, use --path flag")
 			}
 
 			// Find all docker-compose projects
-			projects, err := projectManager.FindProjects(rootPath)
+			projects, err := opts.findProjects(projectManager)
 			if err != nil {
 				return fmt.Errorf("error finding projects: %w", err)
 			}
 
 			if len(projects) == 0 {
-				fmt.Println(outputFormatter.FormatNoProjectsFound())
+				printOutput(cmd, out.FormatNoProjectsFound())
 				return nil
 			}
 
 			if all {
 				// Check status of all projects
-				fmt.Printf("%s🔍 Checking status of %s%d%s Docker Compose projects...%s\n",
-					formatter.ColorBold, formatter.ColorGreen, len(projects), formatter.ColorReset, formatter.ColorReset)
-
-				for _, project := range projects {
-					isRunning, services, err := projectManager.CheckProjectStatus(project)
-					if err != nil {
-						fmt.Printf("%s❌ Error checking status of %s: %v%s\n",
-							formatter.ColorRed, project.Name, err, formatter.ColorReset)
-						continue
-					}
-					fmt.Println(outputFormatter.FormatProjectStatus(project.Name, project.Path, isRunning, services))
-				}
+				printOutput(cmd, out.FormatBulkActionStart("Checking status of", len(projects)))
+
+				statuses := projectManager.GetProjectStatuses(projects)
+				printOutput(cmd, out.FormatStatusList(statuses))
 				return nil
 			}
 
@@ -97,16 +96,16 @@ func newStatusCmd(projectManager *manager.Manager, outputFormatter *formatter.Fo
 			// Find and check status of the specific project
 			project, found := projectManager.FindProject(projects, projectName)
 			if !found {
-				fmt.Println(outputFormatter.FormatProjectNotFound(projectName))
+				printOutput(cmd, out.FormatProjectNotFound(projectName))
 				return nil
 			}
 
-			fmt.Println(outputFormatter.FormatActionStart("Checking status of", project.Name))
-			isRunning, services, err := projectManager.CheckProjectStatus(project)
-			if err != nil {
-				return fmt.Errorf("error checking status of %s: %w", project.Name, err)
+			printOutput(cmd, out.FormatActionStart("Checking status of", project.Name))
+			status := projectManager.GetProjectStatus(project)
+			if status.Error != nil {
+				return fmt.Errorf("error checking status of %s: %w", project.Name, status.Error)
 			}
-			fmt.Println(outputFormatter.FormatProjectStatus(project.Name, project.Path, isRunning, services))
+			printOutput(cmd, out.FormatProjectStatus(status))
 			return nil
 		},
 	}
@@ -114,6 +113,7 @@ func newStatusCmd(projectManager *manager.Manager, outputFormatter *formatter.Fo
 	// Add flags
 	cmd.Flags().BoolVarP(&all, "all", "a", false, "Check status of all docker-compose projects")
 	cmd.Flags().StringVarP(&projectName, "project", "n", "", "Name of the project to check")
+	cmd.Flags().StringVar(&format, "format", "", "Format output using a Go template, e.g. '{{.Project.Name}}\t{{.Running}}'")
 
 	return cmd
 }
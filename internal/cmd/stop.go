@@ -1,85 +1,120 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
-	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/mitas/dcm/internal/config"
 	"github.com/mitas/dcm/internal/manager"
 	"github.com/mitas/dcm/internal/model"
-	"github.com/mitas/dcm/pkg/formatter"
 )
 
 // newStopCmd creates the stop command
-func newStopCmd(projectManager *manager.Manager, outputFormatter *formatter.Formatter) *cobra.Command {
+func newStopCmd(projectManager *manager.Manager, opts *Options) *cobra.Command {
 	var all bool
 	var projectName string
+	var orphan string
+	var force bool
 
 	cmd := &cobra.Command{
 		Use:   "stop [project]",
 		Short: "Stop docker-compose projects",
-		Long:  `Stop one or all docker-compose projects in the specified path or from managed projects.`,
+		Long: `Stop one or all docker-compose projects in the specified path or from managed projects.
+With --orphan, stop a compose project listed as an orphan by 'dcm ps'.
+
+Stopping all projects or an orphan asks for confirmation first, pass --yes
+to skip it. Protected managed projects are only stopped with --force; with
+--all they are left running and reported.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if orphan != "" {
+				return stopOrphan(cmd, projectManager, opts, orphan)
+			}
+
 			// If no project name provided directly, check args
 			if projectName == "" && len(args) > 0 {
 				projectName = args[0]
 			}
 
 			// Check if it's a managed project first
-			if projectName != "" && rootPath == "" {
+			if projectName != "" && !opts.PathsSet {
 				// Load managed config
-				managedConfig, err := config.LoadManagedConfig(configPath)
+				managedConfig, err := opts.loadConfig()
 				if err != nil {
 					return fmt.Errorf("error loading managed projects: %w", err)
 				}
 
 				// Try to find it in managed projects
-				managedProject, found := projectManager.FindManagedProject(managedConfig, projectName)
+				managedProject, found := projectManager.GetManagedProject(managedConfig, projectName)
 				if found {
-					fmt.Println(outputFormatter.FormatActionStart("Stopping managed", managedProject.Alias))
-					result := projectManager.StopProject(managedProject.Project)
-					fmt.Println(outputFormatter.FormatActionResult(result))
+					if err := checkManagedProject(managedProject); err != nil {
+						return err
+					}
+					if managedProject.Protected && !force {
+						return protectedError(managedProject, "stop")
+					}
+					printOutput(cmd, opts.Formatter.FormatActionStart("Stopping managed", managedProject.Alias))
+					ctx, cancel := opts.actionContext(cmd)
+					defer cancel()
+					result := projectManager.StopProject(ctx, managedProject.Project)
+					printOutput(cmd, opts.Formatter.FormatActionResult(result))
 					return nil
 				}
 
 				// Project not found in managed projects
-				if rootPath == "" {
+				if len(opts.Paths) == 0 {
 					return fmt.Errorf("project '%s' not found in managed projects and no --path provided", projectName)
 				}
 			}
 
-			// If we reach here, we need rootPath
-			if rootPath == "" {
+			// If we reach here, we need a root path
+			if len(opts.Paths) == 0 {
 				return fmt.Errorf("path is required to find projects, use --path flag")
 			}
 
 			// Find all docker-compose projects
-			projects, err := projectManager.FindProjects(rootPath)
+			projects, err := opts.findProjects(projectManager)
 			if err != nil {
 				return fmt.Errorf("error finding projects: %w", err)
 			}
 
 			if len(projects) == 0 {
-				fmt.Println(outputFormatter.FormatNoProjectsFound())
+				printOutput(cmd, opts.Formatter.FormatNoProjectsFound())
 				return nil
 			}
 
-			// Set a timeout for the operation
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-			defer cancel()
+			managedConfig, err := opts.loadConfig()
+			if err != nil {
+				return fmt.Errorf("error loading managed projects: %w", err)
+			}
 
 			if all {
-				// Stop all projects
-				fmt.Printf("%s🔄 Stopping %s%d%s Docker Compose projects...%s\n",
-					formatter.ColorBold, formatter.ColorGreen, len(projects), formatter.ColorReset, formatter.ColorReset)
+				// Leave protected projects running unless forced
+				var results []model.Result
+				targets := projects
+				if !force {
+					targets = nil
+					for _, p := range projects {
+						if managedProject, ok := projectManager.ProtectedProject(managedConfig.Projects, p); ok {
+							results = append(results, model.Result{Project: p, Error: protectedError(managedProject, "stop")})
+							continue
+						}
+						targets = append(targets, p)
+					}
+				}
+
+				if len(targets) > 0 {
+					if err := opts.confirm(cmd, "stop "+projectCount(targets)); err != nil {
+						return err
+					}
+
+					ctx, cancel := opts.actionContext(cmd)
+					defer cancel()
 
-				results := projectManager.ManageAllProjects(ctx, projects, model.ActionStop)
-				for _, result := range results {
-					fmt.Println(outputFormatter.FormatActionResult(result))
+					// Stop all projects
+					printOutput(cmd, opts.Formatter.FormatBulkActionStart("Stopping", len(targets)))
+					results = append(projectManager.ManageAllProjects(ctx, targets, model.ActionStop), results...)
 				}
+				printOutput(cmd, opts.Formatter.FormatActionResults(results))
 				return nil
 			}
 
@@ -96,13 +131,18 @@ func newStopCmd(projectManager *manager.Manager, outputFormatter *formatter.Form
 			// Find and stop the specific project
 			project, found := projectManager.FindProject(projects, projectName)
 			if !found {
-				fmt.Println(outputFormatter.FormatProjectNotFound(projectName))
+				printOutput(cmd, opts.Formatter.FormatProjectNotFound(projectName))
 				return nil
 			}
+			if managedProject, ok := projectManager.ProtectedProject(managedConfig.Projects, project); ok && !force {
+				return protectedError(managedProject, "stop")
+			}
 
-			fmt.Println(outputFormatter.FormatActionStart("Stopping", project.Name))
-			result := projectManager.StopProject(project)
-			fmt.Println(outputFormatter.FormatActionResult(result))
+			printOutput(cmd, opts.Formatter.FormatActionStart("Stopping", project.Name))
+			ctx, cancel := opts.actionContext(cmd)
+			defer cancel()
+			result := projectManager.StopProject(ctx, project)
+			printOutput(cmd, opts.Formatter.FormatActionResult(result))
 			return nil
 		},
 	}
@@ -110,6 +150,10 @@ func newStopCmd(projectManager *manager.Manager, outputFormatter *formatter.Form
 	// Add flags
 	cmd.Flags().BoolVarP(&all, "all", "a", false, "Stop all docker-compose projects")
 	cmd.Flags().StringVarP(&projectName, "project", "n", "", "Name of the project to stop")
+	cmd.Flags().BoolVar(&force, "force", false, "Stop protected managed projects too")
+	cmd.Flags().StringVar(&orphan, "orphan", "", "Name of an orphan compose project to stop, as listed by dcm ps")
+
+	opts.bindRetryFlags(cmd)
 
 	return cmd
 }
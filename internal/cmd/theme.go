@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitas/dcm/internal/config"
+	"github.com/mitas/dcm/pkg/formatter"
+)
+
+// buildTheme applies the role overrides of the theme config to its base theme
+func buildTheme(themeConfig *config.ThemeConfig) (formatter.Theme, error) {
+	if themeConfig == nil {
+		return formatter.DefaultTheme, nil
+	}
+
+	theme, err := formatter.ThemeByName(themeConfig.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(formatter.Theme, len(themeConfig.Roles))
+	for role, roleStyle := range themeConfig.Roles {
+		if !formatter.IsRole(role) {
+			return nil, fmt.Errorf("unknown theme role '%s' (valid roles: %s)", role, strings.Join(formatter.Roles, ", "))
+		}
+
+		style := theme[role]
+		if roleStyle.Color != nil {
+			color, err := formatter.ParseColor(*roleStyle.Color)
+			if err != nil {
+				return nil, fmt.Errorf("invalid color for theme role '%s': %w", role, err)
+			}
+			style.Color = color
+		}
+		if roleStyle.Icon != nil {
+			style.Icon = *roleStyle.Icon
+		}
+		overrides[role] = style
+	}
+
+	return theme.With(overrides), nil
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// DefaultContext names the managed projects and settings at the top level
+// of the config file
+const DefaultContext = "default"
+
+// ContextEnv is the environment variable selecting the context
+const ContextEnv = "DCM_CONTEXT"
+
+// Context is a named set of managed projects and settings. It is stored
+// inline in the config file, or in a separate config file when File is set.
+type Context struct {
+	// File is a config file holding the context, relative to the main config file
+	File     string                 `yaml:"file,omitempty"`
+	Projects []model.ManagedProject `yaml:"projects,omitempty"`
+	// Settings override the top-level settings for this context
+	Settings *Settings `yaml:"settings,omitempty"`
+}
+
+// isDefaultContext reports whether name selects the top-level projects
+func isDefaultContext(name string) bool {
+	return name == "" || name == DefaultContext
+}
+
+// contextFile returns the absolute path of a file-backed context
+func contextFile(configPath string, context *Context) string {
+	if filepath.IsAbs(context.File) {
+		return context.File
+	}
+	return filepath.Join(filepath.Dir(configPath), context.File)
+}
+
+// findContext returns a context by name
+func (c *ManagedConfig) findContext(name string) (*Context, error) {
+	context, ok := c.Contexts[name]
+	if !ok || context == nil {
+		return nil, fmt.Errorf("unknown context '%s' (contexts: %s)", name, strings.Join(c.ContextNames(), ", "))
+	}
+	return context, nil
+}
+
+// ContextNames returns the default context followed by the other contexts in order
+func (c *ManagedConfig) ContextNames() []string {
+	names := make([]string, 0, len(c.Contexts))
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{DefaultContext}, names...)
+}
+
+// LoadContextConfig loads the managed projects and settings of a context.
+// Context settings are layered over the top-level settings, and the theme
+// is always the one of the main config file.
+func (s *Store) LoadContextConfig(configPath, name string) (*ManagedConfig, error) {
+	if configPath == "" {
+		configPath = GetDefaultConfigPath()
+	}
+
+	root, err := s.readManagedConfig(configPath)
+	if err != nil || isDefaultContext(name) {
+		return root, err
+	}
+
+	context, err := root.findContext(name)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &ManagedConfig{
+		Version:  CurrentVersion,
+		Projects: context.Projects,
+		Settings: context.Settings,
+	}
+	if context.File != "" {
+		if view, err = s.readManagedConfig(contextFile(configPath, context)); err != nil {
+			return nil, err
+		}
+	}
+	if view.Projects == nil {
+		view.Projects = []model.ManagedProject{}
+	}
+
+	view.Settings = mergeSettings(root.Settings, view.Settings)
+	view.Theme = root.Theme
+	return view, nil
+}
+
+// UpdateContextConfig applies update to the managed projects and settings
+// of a context while holding the lock of the file storing them
+func (s *Store) UpdateContextConfig(configPath, name string, update func(config *ManagedConfig) error) error {
+	if configPath == "" {
+		configPath = GetDefaultConfigPath()
+	}
+	if isDefaultContext(name) {
+		return s.UpdateManagedConfig(configPath, update)
+	}
+
+	root, err := s.readManagedConfig(configPath)
+	if err != nil {
+		return err
+	}
+	context, err := root.findContext(name)
+	if err != nil {
+		return err
+	}
+	if context.File != "" {
+		return s.UpdateManagedConfig(contextFile(configPath, context), update)
+	}
+
+	return s.UpdateManagedConfig(configPath, func(root *ManagedConfig) error {
+		// Look the context up again now that the lock is held
+		context, err := root.findContext(name)
+		if err != nil {
+			return err
+		}
+
+		view := &ManagedConfig{
+			Version:  CurrentVersion,
+			Projects: context.Projects,
+			Settings: context.Settings,
+		}
+		if view.Projects == nil {
+			view.Projects = []model.ManagedProject{}
+		}
+		if err := update(view); err != nil {
+			return err
+		}
+
+		context.Projects = view.Projects
+		context.Settings = view.Settings
+		return nil
+	})
+}
+
+// CreateContext adds an empty context, stored in file when it is not empty
+func CreateContext(config *ManagedConfig, name, file string) error {
+	if isDefaultContext(name) {
+		return fmt.Errorf("context '%s' already exists", DefaultContext)
+	}
+	if _, ok := config.Contexts[name]; ok {
+		return fmt.Errorf("context '%s' already exists", name)
+	}
+
+	if config.Contexts == nil {
+		config.Contexts = make(map[string]*Context)
+	}
+	config.Contexts[name] = &Context{File: file}
+	return nil
+}
+
+// DeleteContext removes a context, switching back to the default context
+// if it was the current one. The file of a file-backed context is kept.
+func DeleteContext(config *ManagedConfig, name string) (*Context, error) {
+	if isDefaultContext(name) {
+		return nil, fmt.Errorf("the default context cannot be deleted")
+	}
+	context, err := config.findContext(name)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(config.Contexts, name)
+	if config.CurrentContext == name {
+		config.CurrentContext = ""
+	}
+	return context, nil
+}
+
+// UseContext makes a context the current one
+func UseContext(config *ManagedConfig, name string) error {
+	if isDefaultContext(name) {
+		config.CurrentContext = ""
+		return nil
+	}
+	if _, err := config.findContext(name); err != nil {
+		return err
+	}
+	config.CurrentContext = name
+	return nil
+}
+
+// mergeSettings layers the set fields of override over base
+func mergeSettings(base, override *Settings) *Settings {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+
+	merged := *base
+	if len(override.Paths) > 0 {
+		merged.Paths = override.Paths
+	}
+	if len(override.Workspaces) > 0 {
+		merged.Workspaces = override.Workspaces
+	}
+	if override.Parallelism != 0 {
+		merged.Parallelism = override.Parallelism
+	}
+	if override.Timeout != 0 {
+		merged.Timeout = override.Timeout
+	}
+	if override.Output != "" {
+		merged.Output = override.Output
+	}
+	if override.Color != "" {
+		merged.Color = override.Color
+	}
+	if override.ComposeBackend != "" {
+		merged.ComposeBackend = override.ComposeBackend
+	}
+	if override.StatusBackend != "" {
+		merged.StatusBackend = override.StatusBackend
+	}
+	if override.Retries != 0 {
+		merged.Retries = override.Retries
+	}
+	if override.RetryBackoff != 0 {
+		merged.RetryBackoff = override.RetryBackoff
+	}
+	return &merged
+}
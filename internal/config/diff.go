@@ -0,0 +1,80 @@
+package config
+
+import "strings"
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// Diff returns a line-based diff of two texts, prefixing removed lines with
+// "-", added lines with "+" and context lines with a space. Long unchanged
+// runs are collapsed to "...". An empty string means the texts are equal.
+func Diff(before, after string) string {
+	a := strings.Split(strings.TrimRight(before, "\n"), "\n")
+	b := strings.Split(strings.TrimRight(after, "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	changed := false
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			lines = append(lines, "+"+b[j])
+			changed = true
+			j++
+		default:
+			lines = append(lines, "-"+a[i])
+			changed = true
+			i++
+		}
+	}
+
+	if !changed {
+		return ""
+	}
+	return strings.Join(collapseContext(lines), "\n")
+}
+
+// collapseContext replaces unchanged lines far from any change with "..."
+func collapseContext(lines []string) []string {
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		if line[0] == ' ' {
+			continue
+		}
+		for k := max(0, i-diffContext); k <= min(len(lines)-1, i+diffContext); k++ {
+			keep[k] = true
+		}
+	}
+
+	var out []string
+	skipped := false
+	for i, line := range lines {
+		if keep[i] {
+			out = append(out, line)
+			skipped = false
+		} else if !skipped {
+			out = append(out, "...")
+			skipped = true
+		}
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// LocalConfigFileName is the project-local config file read next to the compose file
+const LocalConfigFileName = ".dcm.yaml"
+
+// LocalConfig holds the settings a project ships in its own repository
+type LocalConfig struct {
+	// Name overrides the project name derived from its directory
+	Name                  string `yaml:"name,omitempty"`
+	model.ProjectSettings `yaml:",inline"`
+}
+
+// LoadLocalConfig reads the .dcm.yaml in a project directory. It returns
+// nil without error when the project has none.
+func (s *Store) LoadLocalConfig(dir string) (*LocalConfig, error) {
+	path := filepath.Join(dir, LocalConfigFileName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return &LocalConfig{}, nil
+	}
+
+	for _, problem := range unknownFields(doc.Content[0], reflect.TypeOf(LocalConfig{}), "") {
+		s.warnf("%s: %s", path, problem)
+	}
+
+	var local LocalConfig
+	if err := doc.Content[0].Decode(&local); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return &local, nil
+}
+
+// MergeProjectSettings layers the managed settings over the local ones.
+// Each setting set in managed replaces the local value as a whole; sources
+// records which layer every set setting came from.
+func MergeProjectSettings(managed, local model.ProjectSettings) (model.ProjectSettings, map[string]string) {
+	sources := make(map[string]string)
+	pick := func(key string, managedValue, localValue []string) []string {
+		switch {
+		case len(managedValue) > 0:
+			sources[key] = "managed"
+			return managedValue
+		case len(localValue) > 0:
+			sources[key] = "local"
+			return localValue
+		}
+		return nil
+	}
+
+	pickString := func(key, managedValue, localValue string) string {
+		switch {
+		case managedValue != "":
+			sources[key] = "managed"
+			return managedValue
+		case localValue != "":
+			sources[key] = "local"
+			return localValue
+		}
+		return ""
+	}
+
+	// The Docker target is one setting: a managed host replaces a local context
+	dockerContext, dockerHost := local.DockerContext, local.DockerHost
+	if managed.DockerContext != "" || managed.DockerHost != "" {
+		dockerContext, dockerHost = managed.DockerContext, managed.DockerHost
+	}
+
+	env := local.Env
+	if len(managed.Env) > 0 {
+		sources["env"] = "managed"
+		env = managed.Env
+	} else if len(local.Env) > 0 {
+		sources["env"] = "local"
+	}
+
+	return model.ProjectSettings{
+		Profiles:     pick("profiles", managed.Profiles, local.Profiles),
+		ComposeFiles: pick("compose_files", managed.ComposeFiles, local.ComposeFiles),
+		EnvFiles:     pick("env_files", managed.EnvFiles, local.EnvFiles),
+		DependsOn:    pick("depends_on", managed.DependsOn, local.DependsOn),
+		Hooks: model.Hooks{
+			PreStart:  pick("hooks.pre_start", managed.Hooks.PreStart, local.Hooks.PreStart),
+			PostStart: pick("hooks.post_start", managed.Hooks.PostStart, local.Hooks.PostStart),
+			PreStop:   pick("hooks.pre_stop", managed.Hooks.PreStop, local.Hooks.PreStop),
+			PostStop:  pick("hooks.post_stop", managed.Hooks.PostStop, local.Hooks.PostStop),
+		},
+		DockerContext:  pickString("docker_context", managed.DockerContext, dockerContext),
+		DockerHost:     pickString("docker_host", managed.DockerHost, dockerHost),
+		Env:            env,
+		ComposeBackend: pickString("compose_backend", managed.ComposeBackend, local.ComposeBackend),
+	}, sources
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockTimeout is how long to wait for another dcm process to release the config lock
+const lockTimeout = 10 * time.Second
+
+// lockRetryInterval is how often a held lock is retried
+const lockRetryInterval = 50 * time.Millisecond
+
+// lockPath returns the path of the lock file guarding a config file.
+// A separate file is used so the lock survives the config being replaced.
+func lockPath(configPath string) string {
+	return configPath + ".lock"
+}
+
+// lockConfig takes an exclusive advisory lock on the config file and returns
+// a function releasing it. In dry-run mode nothing is written, so no lock
+// is taken and no lock file is created.
+func (s *Store) lockConfig(configPath string) (func(), error) {
+	if s.dryRun != nil {
+		return func() {}, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return nil, fmt.Errorf("error creating config directory: %w", err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		unlock, locked, err := tryLock(lockPath(configPath))
+		if err != nil {
+			return nil, fmt.Errorf("error locking config file: %w", err)
+		}
+		if locked {
+			return unlock, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for config lock %s held by another dcm process", lockPath(configPath))
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !windows
+
+package config
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLock attempts to take a flock on the lock file without blocking
+func tryLock(path string) (func(), bool, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	unlock := func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}
+	return unlock, true, nil
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// staleLockAge is the age after which a lock file left by a crashed process is ignored
+const staleLockAge = time.Minute
+
+// tryLock attempts to create the lock file exclusively. Windows has no flock,
+// so the existence of the file is the lock.
+func tryLock(path string) (func(), bool, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if !os.IsExist(err) {
+			return nil, false, err
+		}
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+		}
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		file.Close()
+		os.Remove(path)
+	}
+	return unlock, true, nil
+}
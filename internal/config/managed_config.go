@@ -1,9 +1,12 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
@@ -12,11 +15,76 @@ import (
 
 // ManagedConfig represents the configuration for managed projects
 type ManagedConfig struct {
+	// Version is the schema version of the file, see CurrentVersion
+	Version  int                    `yaml:"version"`
 	Projects []model.ManagedProject `yaml:"projects"`
+	Settings *Settings              `yaml:"settings,omitempty"`
+	Theme    *ThemeConfig           `yaml:"theme,omitempty"`
+	// CurrentContext is the context used when --context is not given,
+	// empty for the projects and settings above
+	CurrentContext string `yaml:"current_context,omitempty"`
+	// Contexts are alternative sets of managed projects and settings
+	Contexts map[string]*Context `yaml:"contexts,omitempty"`
 }
 
-// GetDefaultConfigPath returns the default config file path
+// Settings provide defaults for the global flags. DCM_* environment
+// variables override them, and flags override both.
+type Settings struct {
+	// Paths are searched for projects when neither --path nor --workspace is given
+	Paths []string `yaml:"paths,omitempty"`
+	// Workspaces name sets of root paths selected with --workspace
+	Workspaces map[string][]string `yaml:"workspaces,omitempty"`
+	// Parallelism limits how many projects bulk actions handle at once,
+	// 0 means no limit
+	Parallelism int `yaml:"parallelism,omitempty"`
+	// Timeout bounds bulk actions, e.g. 10m
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Output is the default output format: text, json, yaml or table
+	Output string `yaml:"output,omitempty"`
+	// Color is the default color mode: auto, always or never
+	Color string `yaml:"color,omitempty"`
+	// ComposeBackend is the compose engine of projects without their own:
+	// auto, docker, docker-compose, podman or nerdctl
+	ComposeBackend string `yaml:"compose_backend,omitempty"`
+	// StatusBackend is how status is checked: compose, or engine to query
+	// the Docker Engine API once for every project
+	StatusBackend string `yaml:"status_backend,omitempty"`
+	// Retries is how many times start and stop retry transient failures
+	Retries int `yaml:"retries,omitempty"`
+	// RetryBackoff is the wait before the first retry, doubled after each
+	RetryBackoff time.Duration `yaml:"retry_backoff,omitempty"`
+}
+
+// ThemeConfig selects a built-in output theme and overrides individual roles
+type ThemeConfig struct {
+	// Name is the built-in theme to start from (default, light or plain)
+	Name string `yaml:"name,omitempty"`
+	// Roles overrides the style of semantic roles such as success or path
+	Roles map[string]RoleStyle `yaml:"roles,omitempty"`
+}
+
+// RoleStyle overrides the color and icon of a role.
+// Unset fields keep the value from the base theme, an empty icon removes it.
+type RoleStyle struct {
+	// Color is a space separated list of color names, e.g. "bold blue"
+	Color *string `yaml:"color,omitempty"`
+	Icon  *string `yaml:"icon,omitempty"`
+}
+
+// ConfigPathEnv is the environment variable overriding the config file path
+const ConfigPathEnv = "DCM_CONFIG"
+
+// GetDefaultConfigPath returns the default config file path: $DCM_CONFIG,
+// then $XDG_CONFIG_HOME/dcm/config.yaml, then ~/.config/dcm/config.yaml
 func GetDefaultConfigPath() string {
+	if path := os.Getenv(ConfigPathEnv); path != "" {
+		return path
+	}
+
+	if xdgConfigHome := os.Getenv("XDG_CONFIG_HOME"); xdgConfigHome != "" {
+		return filepath.Join(xdgConfigHome, "dcm", "config.yaml")
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return ""
@@ -26,71 +94,270 @@ func GetDefaultConfigPath() string {
 	return filepath.Join(configDir, "config.yaml")
 }
 
-// LoadManagedConfig loads the managed projects configuration from a file
-func LoadManagedConfig(configPath string) (*ManagedConfig, error) {
+// LoadManagedConfig loads the managed projects configuration from a file.
+// A missing file yields an empty config; it is only created on the first write.
+func (s *Store) LoadManagedConfig(configPath string) (*ManagedConfig, error) {
 	if configPath == "" {
 		configPath = GetDefaultConfigPath()
 	}
 
-	// If config file doesn't exist, create default config
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		fmt.Printf("Config file not found at %s, creating default config\n", configPath)
-		return createDefaultConfig(configPath)
-	}
+	return s.readManagedConfig(configPath)
+}
 
+// readManagedConfig reads and parses a config file, returning an empty
+// config if the file does not exist
+func (s *Store) readManagedConfig(configPath string) (*ManagedConfig, error) {
 	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return newManagedConfig(), nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
 
+	config, _, err := s.parseManagedConfig(data)
+	return config, err
+}
+
+// newManagedConfig returns an empty config at the current schema version
+func newManagedConfig() *ManagedConfig {
+	return &ManagedConfig{
+		Version:  CurrentVersion,
+		Projects: []model.ManagedProject{},
+	}
+}
+
+// parseManagedConfig parses a config file, migrating older schema versions
+// in memory and warning about unknown fields. It returns the version the
+// file was written with.
+func (s *Store) parseManagedConfig(data []byte) (*ManagedConfig, int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("error parsing config file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return newManagedConfig(), CurrentVersion, nil
+	}
+	root := doc.Content[0]
+
+	from, err := migrateDocument(root)
+	if err != nil {
+		return nil, from, err
+	}
+
+	for _, problem := range unknownFields(root, reflect.TypeOf(ManagedConfig{}), "") {
+		s.warnf("%s", problem)
+	}
+
 	var config ManagedConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("error parsing config file: %w", err)
+	if err := root.Decode(&config); err != nil {
+		return nil, from, fmt.Errorf("error parsing config file: %w", err)
+	}
+	if config.Projects == nil {
+		config.Projects = []model.ManagedProject{}
 	}
 
-	return &config, nil
+	return &config, from, nil
 }
 
-// SaveManagedConfig saves the managed projects configuration to a file
-func SaveManagedConfig(config *ManagedConfig, configPath string) error {
+// SaveManagedConfig saves the managed projects configuration to a file.
+// Use UpdateManagedConfig for read-modify-write cycles so concurrent
+// dcm processes cannot lose each other's changes.
+func (s *Store) SaveManagedConfig(config *ManagedConfig, configPath string) error {
 	if configPath == "" {
 		configPath = GetDefaultConfigPath()
 	}
 
+	unlock, err := s.lockConfig(configPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.saveManagedConfig(config, configPath)
+}
+
+// UpdateManagedConfig loads the config, applies update and saves the result
+// while holding the config lock. Nothing is written if update fails.
+func (s *Store) UpdateManagedConfig(configPath string, update func(config *ManagedConfig) error) error {
+	if configPath == "" {
+		configPath = GetDefaultConfigPath()
+	}
+
+	unlock, err := s.lockConfig(configPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := s.readManagedConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	if err := update(config); err != nil {
+		return err
+	}
+
+	return s.saveManagedConfig(config, configPath)
+}
+
+// saveManagedConfig atomically replaces the config file, keeping the
+// previous version as a .bak file. The caller must hold the config lock.
+func (s *Store) saveManagedConfig(config *ManagedConfig, configPath string) error {
+	if s.dryRun != nil {
+		data, err := MarshalManagedConfig(config)
+		if err != nil {
+			return err
+		}
+		s.printDryRun(configPath, data)
+		return nil
+	}
+
 	// Create directory if it doesn't exist
 	configDir := filepath.Dir(configPath)
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return fmt.Errorf("error creating config directory: %w", err)
 	}
 
-	data, err := yaml.Marshal(config)
+	data, err := MarshalManagedConfig(config)
 	if err != nil {
-		return fmt.Errorf("error serializing config: %w", err)
+		return err
+	}
+
+	_, statErr := os.Stat(configPath)
+	created := os.IsNotExist(statErr)
+
+	if err := backupConfig(configPath); err != nil {
+		return err
 	}
 
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	if err := writeFileAtomic(configPath, data, 0644); err != nil {
 		return fmt.Errorf("error writing config file: %w", err)
 	}
 
+	if created {
+		s.notef("Created config file at %s", configPath)
+	}
 	return nil
 }
 
-// createDefaultConfig creates a default config file
-func createDefaultConfig(configPath string) (*ManagedConfig, error) {
-	config := &ManagedConfig{
-		Projects: []model.ManagedProject{},
+// ReplaceManagedConfig validates raw YAML and, if it parses as a config,
+// atomically writes it as the config file while holding the config lock.
+// The data is written as given so comments and formatting are preserved.
+func (s *Store) ReplaceManagedConfig(configPath string, data []byte) (*ManagedConfig, error) {
+	if configPath == "" {
+		configPath = GetDefaultConfigPath()
 	}
 
-	// Create directory if it doesn't exist
-	configDir := filepath.Dir(configPath)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return nil, fmt.Errorf("error creating config directory: %w", err)
+	config, _, err := s.parseManagedConfig(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.dryRun != nil {
+		s.printDryRun(configPath, data)
+		return config, nil
 	}
 
-	// Save empty config
-	if err := SaveManagedConfig(config, configPath); err != nil {
+	unlock, err := s.lockConfig(configPath)
+	if err != nil {
 		return nil, err
 	}
+	defer unlock()
+
+	if err := backupConfig(configPath); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(configPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("error writing config file: %w", err)
+	}
 
 	return config, nil
 }
+
+// MarshalManagedConfig serializes a config at the current schema version
+func MarshalManagedConfig(config *ManagedConfig) ([]byte, error) {
+	config.Version = CurrentVersion
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(config); err != nil {
+		return nil, fmt.Errorf("error serializing config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("error serializing config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// BackupPath returns the path of the backup kept for a config file
+func BackupPath(configPath string) string {
+	return configPath + ".bak"
+}
+
+// backupConfig copies the current config file, if any, to its backup path.
+// A file written with an older schema version is also preserved under a
+// versioned name that later saves do not overwrite.
+func backupConfig(configPath string) error {
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading config file for backup: %w", err)
+	}
+
+	if err := writeFileAtomic(BackupPath(configPath), data, 0644); err != nil {
+		return fmt.Errorf("error writing config backup: %w", err)
+	}
+
+	var doc yaml.Node
+	if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	version, err := documentVersion(doc.Content[0])
+	if err != nil || version >= CurrentVersion {
+		return nil
+	}
+
+	versionBackup := versionBackupPath(configPath, version)
+	if _, err := os.Stat(versionBackup); err == nil {
+		return nil
+	}
+	if err := writeFileAtomic(versionBackup, data, 0644); err != nil {
+		return fmt.Errorf("error writing config backup: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and
+// renames it over path, so readers never see a partially written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	// Clean up the temporary file if anything below fails
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// writerAliasEnv makes the test binary act as a dcm process adding one alias
+const writerAliasEnv = "DCM_TEST_WRITER_ALIAS"
+
+// writerConfigEnv is the config file the writer process updates
+const writerConfigEnv = "DCM_TEST_WRITER_CONFIG"
+
+// addAlias adds a managed project named after alias through UpdateManagedConfig
+func addAlias(store *Store, configPath, alias string) error {
+	return store.UpdateManagedConfig(configPath, func(config *ManagedConfig) error {
+		config.Projects = append(config.Projects, model.ManagedProject{
+			Alias:   alias,
+			Project: model.Project{Name: alias, Path: "/srv/" + alias, File: "docker-compose.yml"},
+		})
+		return nil
+	})
+}
+
+// TestWriterProcess is run in a subprocess by TestConcurrentWriters
+func TestWriterProcess(t *testing.T) {
+	alias := os.Getenv(writerAliasEnv)
+	if alias == "" {
+		t.Skip("only run as a writer subprocess")
+	}
+	if err := addAlias(NewStore(os.Stderr), os.Getenv(writerConfigEnv), alias); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	const writers = 8
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	store := NewStore(io.Discard)
+
+	// Start from an existing file so every writer makes a backup
+	if err := store.SaveManagedConfig(newManagedConfig(), configPath); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		alias := fmt.Sprintf("p%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestWriterProcess$")
+			cmd.Env = append(os.Environ(), writerAliasEnv+"="+alias, writerConfigEnv+"="+configPath)
+			if output, err := cmd.CombinedOutput(); err != nil {
+				errs <- fmt.Errorf("writer %s failed: %w: %s", alias, err, output)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	config, err := store.LoadManagedConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Projects) != writers {
+		t.Fatalf("got %d managed projects, want %d: %+v", len(config.Projects), writers, config.Projects)
+	}
+	seen := make(map[string]bool)
+	for _, p := range config.Projects {
+		seen[p.Alias] = true
+	}
+	for i := 0; i < writers; i++ {
+		if alias := fmt.Sprintf("p%d", i); !seen[alias] {
+			t.Errorf("alias %s was lost", alias)
+		}
+	}
+
+	// The backup is the version before the last write
+	backup, err := store.LoadManagedConfig(BackupPath(configPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backup.Projects) != writers-1 {
+		t.Fatalf("backup has %d managed projects, want %d", len(backup.Projects), writers-1)
+	}
+	for i, p := range backup.Projects {
+		if p.Alias != config.Projects[i].Alias {
+			t.Errorf("backup project %d is %s, want %s", i, p.Alias, config.Projects[i].Alias)
+		}
+	}
+}
+
+func TestDryRunLeavesConfigUntouched(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	store := NewStore(io.Discard)
+	if err := addAlias(store, configPath, "api"); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	store.SetDryRun(&out)
+
+	if err := addAlias(store, configPath, "web"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Would update "+configPath) || !strings.Contains(out.String(), "+  - alias: web") {
+		t.Errorf("dry run printed:\n%s\nwant the added project", out.String())
+	}
+
+	after, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Errorf("config changed in dry run:\n%s", after)
+	}
+	if _, err := os.Stat(BackupPath(configPath)); !os.IsNotExist(err) {
+		t.Error("backup was created in dry run")
+	}
+
+	out.Reset()
+	newPath := filepath.Join(dir, "new", "config.yaml")
+	if err := addAlias(store, newPath, "api"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Would create "+newPath) {
+		t.Errorf("dry run printed:\n%s\nwant the created file", out.String())
+	}
+	if _, err := os.Stat(filepath.Dir(newPath)); !os.IsNotExist(err) {
+		t.Error("config directory was created in dry run")
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// ManifestVersion is the manifest schema version written by this release
+const ManifestVersion = 1
+
+// LoadManifest reads a team manifest, warning about unknown fields
+func (s *Store) LoadManifest(path string) (*model.Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("manifest %s is empty", path)
+	}
+	root := doc.Content[0]
+
+	for _, problem := range unknownFields(root, reflect.TypeOf(model.Manifest{}), "") {
+		s.warnf("manifest: %s", problem)
+	}
+
+	var manifest model.Manifest
+	if err := root.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %w", err)
+	}
+	if manifest.Version > ManifestVersion {
+		return nil, fmt.Errorf("manifest version %d is newer than the supported version %d, please upgrade dcm", manifest.Version, ManifestVersion)
+	}
+
+	return &manifest, nil
+}
+
+// MarshalManifest serializes a manifest at the current schema version
+func MarshalManifest(manifest *model.Manifest) ([]byte, error) {
+	manifest.Version = ManifestVersion
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(manifest); err != nil {
+		return nil, fmt.Errorf("error serializing manifest: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("error serializing manifest: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// CurrentVersion is the config schema version written by this release
+const CurrentVersion = 1
+
+// migration upgrades the root mapping of a config document by one version
+type migration func(root *yaml.Node) error
+
+// migrations holds the step upgrading a config from version N to N+1 at index N
+var migrations = []migration{
+	// 0 -> 1: files written before versioning only gain the version key
+	func(root *yaml.Node) error {
+		return nil
+	},
+}
+
+// documentVersion returns the schema version of a config document,
+// 0 when the version key is missing
+func documentVersion(root *yaml.Node) (int, error) {
+	node := mappingValue(root, "version")
+	if node == nil {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(node.Value)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid config version '%s'", node.Value)
+	}
+	return version, nil
+}
+
+// migrateDocument upgrades a config document step by step to CurrentVersion
+// and returns the version it started from
+func migrateDocument(root *yaml.Node) (int, error) {
+	from, err := documentVersion(root)
+	if err != nil {
+		return 0, err
+	}
+	if from > CurrentVersion {
+		return from, fmt.Errorf("config version %d is newer than the supported version %d, please upgrade dcm", from, CurrentVersion)
+	}
+
+	for version := from; version < CurrentVersion; version++ {
+		if err := migrations[version](root); err != nil {
+			return from, fmt.Errorf("error migrating config from version %d to %d: %w", version, version+1, err)
+		}
+		setMappingValue(root, "version", strconv.Itoa(version+1))
+	}
+
+	return from, nil
+}
+
+// mappingValue returns the value node stored under key in a mapping node
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets a scalar value in a mapping node, adding the key first if missing
+func setMappingValue(mapping *yaml.Node, key, value string) {
+	if node := mappingValue(mapping, key); node != nil {
+		node.Kind = yaml.ScalarNode
+		node.Tag = "!!int"
+		node.Value = value
+		return
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	valueNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}
+	mapping.Content = append([]*yaml.Node{keyNode, valueNode}, mapping.Content...)
+}
+
+// MigrateManagedConfig upgrades a config file to CurrentVersion. The previous
+// file is kept as a versioned backup. With dryRun nothing is written and the
+// result only describes the changes.
+func (s *Store) MigrateManagedConfig(configPath string, dryRun bool) (model.MigrationResult, error) {
+	if configPath == "" {
+		configPath = GetDefaultConfigPath()
+	}
+
+	result := model.MigrationResult{
+		Path:      configPath,
+		ToVersion: CurrentVersion,
+		DryRun:    dryRun,
+	}
+
+	// Read without the lock first so dry runs and files already at the
+	// current version leave nothing behind, not even the lock file
+	if _, err := s.planMigration(configPath, &result); err != nil {
+		return result, err
+	}
+	if result.FromVersion == CurrentVersion || dryRun {
+		return result, nil
+	}
+
+	unlock, err := s.lockConfig(configPath)
+	if err != nil {
+		return result, err
+	}
+	defer unlock()
+
+	// Plan again in case another dcm process changed the file meanwhile
+	config, err := s.planMigration(configPath, &result)
+	if err != nil {
+		return result, err
+	}
+	if result.FromVersion == CurrentVersion {
+		return result, nil
+	}
+
+	if err := s.saveManagedConfig(config, configPath); err != nil {
+		return result, err
+	}
+	result.Backup = versionBackupPath(configPath, result.FromVersion)
+
+	return result, nil
+}
+
+// planMigration reads a config file and fills in the version it was written
+// with and the changes migrating it makes, returning the migrated config
+func (s *Store) planMigration(configPath string, result *model.MigrationResult) (*ManagedConfig, error) {
+	before, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	config, from, err := s.parseManagedConfig(before)
+	if err != nil {
+		return nil, err
+	}
+	result.FromVersion = from
+
+	after, err := MarshalManagedConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	result.Diff = Diff(string(before), string(after))
+
+	return config, nil
+}
+
+// versionBackupPath returns the path where a config file of the given version
+// is preserved before being migrated
+func versionBackupPath(configPath string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", configPath, version)
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Store reads and writes config files. Notices and non-fatal problems about
+// the files go to its messages writer, each warning once, as a command may
+// load the config several times.
+type Store struct {
+	messages io.Writer
+	// dryRun receives the changes writes would make, which then leave the
+	// files untouched. It is nil when files are written.
+	dryRun io.Writer
+
+	warnedMu sync.Mutex
+	warned   map[string]bool
+}
+
+// NewStore creates a store writing its notices and warnings to messages,
+// usually stderr so machine-readable output on stdout stays clean
+func NewStore(messages io.Writer) *Store {
+	return &Store{messages: messages, warned: make(map[string]bool)}
+}
+
+// SetDryRun makes the store print to out how writes would change the config
+// files instead of writing them, taking no lock and creating nothing
+func (s *Store) SetDryRun(out io.Writer) {
+	s.dryRun = out
+}
+
+// warnf writes a warning about a config file, once per store
+func (s *Store) warnf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+
+	s.warnedMu.Lock()
+	defer s.warnedMu.Unlock()
+	if s.warned[message] {
+		return
+	}
+	s.warned[message] = true
+
+	fmt.Fprintf(s.messages, "Warning: %s\n", message)
+}
+
+// notef writes a notice about a config file
+func (s *Store) notef(format string, args ...interface{}) {
+	fmt.Fprintf(s.messages, format+"\n", args...)
+}
+
+// printDryRun describes how writing data would change a file
+func (s *Store) printDryRun(path string, data []byte) {
+	before, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		fmt.Fprintf(s.dryRun, "Would create %s (dry run):\n%s\n", path, strings.TrimRight(string(data), "\n"))
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(s.dryRun, "Would write %s (dry run), it cannot be read: %v\n", path, err)
+		return
+	}
+
+	diff := Diff(string(before), string(data))
+	if diff == "" {
+		fmt.Fprintf(s.dryRun, "No changes to %s (dry run)\n", path)
+		return
+	}
+	fmt.Fprintf(s.dryRun, "Would update %s (dry run):\n%s\n", path, strings.TrimRight(diff, "\n"))
+}
+
+// unknownFields walks a YAML node alongside the Go type it decodes into and
+// describes every mapping key that does not correspond to a field
+func unknownFields(node *yaml.Node, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var problems []string
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldPath := joinPath(path, key.Value)
+			field, ok := fields[key.Value]
+			if !ok {
+				problems = append(problems, unknownFieldMessage(key, fieldPath, fields))
+				continue
+			}
+			problems = append(problems, unknownFields(value, field.Type, fieldPath)...)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, item := range node.Content {
+			problems = append(problems, unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			problems = append(problems, unknownFields(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))...)
+		}
+	}
+	return problems
+}
+
+// yamlFields returns the fields of a struct type keyed by their YAML name
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		// Fields of inlined structs are keys of the enclosing mapping
+		if len(tag) > 1 && tag[1] == "inline" && field.Type.Kind() == reflect.Struct {
+			for inlineName, inlineField := range yamlFields(field.Type) {
+				fields[inlineName] = inlineField
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// unknownFieldMessage describes an unknown key, suggesting the closest known field
+func unknownFieldMessage(key *yaml.Node, path string, fields map[string]reflect.StructField) string {
+	message := fmt.Sprintf("unknown config field '%s' at line %d is ignored", path, key.Line)
+
+	best, bestDistance := "", len(key.Value)/2+1
+	for name := range fields {
+		if d := editDistance(key.Value, name); d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	if best != "" {
+		message += fmt.Sprintf(", did you mean '%s'?", best)
+	}
+	return message
+}
+
+// joinPath appends a key to a dotted field path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
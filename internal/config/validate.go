@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// ComposeFileNames are the file names docker compose looks for by default
+var ComposeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// ValidateManagedConfig checks that every managed project still exists on
+// disk and that no alias or project path is registered twice
+func ValidateManagedConfig(config *ManagedConfig) []model.ConfigIssue {
+	var issues []model.ConfigIssue
+	aliases := make(map[string]string)
+	paths := make(map[string]string)
+
+	for _, p := range config.Projects {
+		issue := func(format string, args ...interface{}) {
+			issues = append(issues, model.ConfigIssue{Alias: p.Alias, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if p.Alias == "" {
+			issue("alias is empty")
+		} else if other, ok := aliases[strings.ToLower(p.Alias)]; ok {
+			issue("alias duplicates '%s'", other)
+		} else {
+			aliases[strings.ToLower(p.Alias)] = p.Alias
+		}
+
+		if other, ok := paths[p.Project.Path]; ok {
+			issue("path %s is also managed as '%s'", p.Project.Path, other)
+		} else {
+			paths[p.Project.Path] = p.Alias
+		}
+
+		if problem := CheckProjectFiles(p.Project); problem != "" {
+			issue("%s", problem)
+		}
+	}
+
+	return issues
+}
+
+// CheckProjectFiles reports why a project's directory or compose file is
+// missing, or an empty string if both exist
+func CheckProjectFiles(project model.Project) string {
+	if project.Path == "" {
+		return "project path is empty"
+	}
+
+	info, err := os.Stat(project.Path)
+	if err != nil {
+		return fmt.Sprintf("project directory %s does not exist", project.Path)
+	}
+	if !info.IsDir() {
+		return fmt.Sprintf("project path %s is not a directory", project.Path)
+	}
+
+	if project.File != "" {
+		composePath := filepath.Join(project.Path, project.File)
+		if _, err := os.Stat(composePath); err != nil {
+			return fmt.Sprintf("compose file %s does not exist", composePath)
+		}
+	} else if FindComposeFile(project.Path) == "" {
+		return fmt.Sprintf("no compose file found in %s", project.Path)
+	}
+
+	for _, envFile := range project.EnvFiles {
+		if !filepath.IsAbs(envFile) {
+			envFile = filepath.Join(project.Path, envFile)
+		}
+		if _, err := os.Stat(envFile); err != nil {
+			return fmt.Sprintf("env file %s does not exist", envFile)
+		}
+	}
+	return ""
+}
+
+// FindComposeFile returns the name of the default compose file in dir,
+// or an empty string if there is none
+func FindComposeFile(dir string) string {
+	for _, name := range ComposeFileNames {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,397 @@
+package manager
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mitas/dcm/internal/config"
+	"github.com/mitas/dcm/internal/model"
+)
+
+// Names of the compose backends
+const (
+	// BackendAuto selects the first backend found on the system
+	BackendAuto = "auto"
+	// BackendDocker is the docker compose v2 plugin
+	BackendDocker = "docker"
+	// BackendDockerCompose is the legacy docker-compose v1 binary
+	BackendDockerCompose = "docker-compose"
+	// BackendPodman is podman compose
+	BackendPodman = "podman"
+	// BackendNerdctl is nerdctl compose for containerd
+	BackendNerdctl = "nerdctl"
+)
+
+// BackendNames lists the backend names accepted by ParseBackend, in the
+// order auto-detection tries them
+var BackendNames = []string{BackendDocker, BackendDockerCompose, BackendPodman, BackendNerdctl}
+
+// Container describes a container of a compose project
+type Container struct {
+	Name    string
+	Service string
+	// State is the container state, e.g. running or exited
+	State string
+	// Status is the human-readable status, e.g. "Up 5 minutes"
+	Status string
+	Health string
+	// Ports lists the published ports, e.g. 8080->80/tcp
+	Ports []string
+}
+
+// ComposeCommand is a compose invocation built by a backend
+type ComposeCommand struct {
+	Program string
+	Args    []string
+	// Env holds KEY=value variables added to the environment of dcm
+	Env []string
+}
+
+// Backend builds the commands of a compose engine and parses its output
+type Backend interface {
+	// Name returns the name of the backend, as used in the config file
+	Name() string
+	// VersionCommand returns a command that succeeds when the backend is installed
+	VersionCommand() ComposeCommand
+	// Command returns the command running a compose subcommand for a
+	// resolved project on its Docker target
+	Command(project model.Project, args ...string) (ComposeCommand, error)
+	// ContainersArgs returns the compose subcommand listing the containers
+	// of a project, including stopped ones
+	ContainersArgs() []string
+	// ParseContainers parses the output of ContainersArgs
+	ParseContainers(output []byte) ([]Container, error)
+}
+
+// ProjectLister is implemented by backends that can list every compose
+// project known to the engine
+type ProjectLister interface {
+	// ListProjectsCommand returns the command listing the compose projects
+	ListProjectsCommand() ComposeCommand
+	// ParseProjects parses the output of ListProjectsCommand
+	ParseProjects(output []byte) ([]model.EngineProject, error)
+}
+
+// ParseBackend returns the backend with the given name; auto and an empty
+// name return nil, leaving the choice to auto-detection
+func ParseBackend(name string) (Backend, error) {
+	switch name {
+	case "", BackendAuto:
+		return nil, nil
+	case BackendDocker:
+		return dockerBackend{}, nil
+	case BackendDockerCompose:
+		return dockerComposeBackend{}, nil
+	case BackendPodman:
+		return podmanBackend{}, nil
+	case BackendNerdctl:
+		return nerdctlBackend{}, nil
+	}
+	return nil, fmt.Errorf("unknown compose backend '%s' (valid backends: %s, %s)", name, BackendAuto, strings.Join(BackendNames, ", "))
+}
+
+// DetectBackend returns the first backend whose version command succeeds
+func DetectBackend(executor CommandExecutor) (Backend, error) {
+	for _, name := range BackendNames {
+		backend, _ := ParseBackend(name)
+		command := backend.VersionCommand()
+		if _, err := executor.Execute("", command.Program, command.Args...); err == nil {
+			return backend, nil
+		}
+	}
+	return nil, fmt.Errorf("no compose backend found, install one of: docker compose, docker-compose, podman compose, nerdctl compose")
+}
+
+// composeFlags returns the flags selecting the compose files, env files and
+// profiles of a resolved project, which every backend accepts
+func composeFlags(project model.Project) []string {
+	var flags []string
+
+	file := project.File
+	if file == "" && len(project.ComposeFiles) > 0 {
+		// Extra files replace the default lookup, so name the base file too
+		file = config.FindComposeFile(project.Path)
+	}
+	if file != "" {
+		flags = append(flags, "-f", file)
+	}
+	for _, composeFile := range project.ComposeFiles {
+		flags = append(flags, "-f", composeFile)
+	}
+	for _, envFile := range project.EnvFiles {
+		flags = append(flags, "--env-file", envFile)
+	}
+	for _, profile := range project.Profiles {
+		flags = append(flags, "--profile", profile)
+	}
+	return flags
+}
+
+// projectEnv returns the environment variables of a resolved project,
+// sorted by name, with hostVar set to its Docker host when hostVar is not empty
+func projectEnv(project model.Project, hostVar string) []string {
+	env := make([]string, 0, len(project.Env)+1)
+	for key, value := range project.Env {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	if project.DockerHost != "" && hostVar != "" {
+		env = append(env, hostVar+"="+project.DockerHost)
+	}
+	return env
+}
+
+// dockerBackend runs the docker compose v2 plugin
+type dockerBackend struct{}
+
+func (dockerBackend) Name() string { return BackendDocker }
+
+func (dockerBackend) VersionCommand() ComposeCommand {
+	return ComposeCommand{Program: "docker", Args: []string{"compose", "version"}}
+}
+
+func (dockerBackend) Command(project model.Project, args ...string) (ComposeCommand, error) {
+	var commandArgs []string
+	if project.DockerContext != "" {
+		commandArgs = append(commandArgs, "--context", project.DockerContext)
+	}
+	commandArgs = append(commandArgs, "compose")
+	commandArgs = append(commandArgs, composeFlags(project)...)
+	return ComposeCommand{
+		Program: "docker",
+		Args:    append(commandArgs, args...),
+		Env:     projectEnv(project, "DOCKER_HOST"),
+	}, nil
+}
+
+func (dockerBackend) ContainersArgs() []string {
+	return []string{"ps", "-a", "--format", "json"}
+}
+
+func (dockerBackend) ParseContainers(output []byte) ([]Container, error) {
+	return parseComposePS(output)
+}
+
+func (dockerBackend) ListProjectsCommand() ComposeCommand {
+	return ComposeCommand{Program: "docker", Args: []string{"compose", "ls", "-a", "--format", "json"}}
+}
+
+// ParseProjects parses the output of `docker compose ls --format json`.
+// The working directory is the one of the first compose file.
+func (dockerBackend) ParseProjects(output []byte) ([]model.EngineProject, error) {
+	var entries []struct {
+		Name        string `json:"Name"`
+		Status      string `json:"Status"`
+		ConfigFiles string `json:"ConfigFiles"`
+	}
+	if trimmed := bytes.TrimSpace(output); len(trimmed) > 0 {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("error parsing project list: %w", err)
+		}
+	}
+
+	projects := make([]model.EngineProject, 0, len(entries))
+	for _, entry := range entries {
+		project := model.EngineProject{Name: entry.Name, Status: entry.Status}
+		if entry.ConfigFiles != "" {
+			project.ConfigFiles = strings.Split(entry.ConfigFiles, ",")
+			project.WorkingDir = filepath.Dir(project.ConfigFiles[0])
+		}
+		projects = append(projects, project)
+	}
+	return projects, nil
+}
+
+// dockerComposeBackend runs the legacy docker-compose v1 binary, which has
+// no JSON output
+type dockerComposeBackend struct{}
+
+func (dockerComposeBackend) Name() string { return BackendDockerCompose }
+
+func (dockerComposeBackend) VersionCommand() ComposeCommand {
+	return ComposeCommand{Program: "docker-compose", Args: []string{"version"}}
+}
+
+func (dockerComposeBackend) Command(project model.Project, args ...string) (ComposeCommand, error) {
+	var commandArgs []string
+	if project.DockerContext != "" {
+		commandArgs = append(commandArgs, "--context", project.DockerContext)
+	}
+	commandArgs = append(commandArgs, composeFlags(project)...)
+	return ComposeCommand{
+		Program: "docker-compose",
+		Args:    append(commandArgs, args...),
+		Env:     projectEnv(project, "DOCKER_HOST"),
+	}, nil
+}
+
+func (dockerComposeBackend) ContainersArgs() []string {
+	return []string{"ps", "-a"}
+}
+
+// legacyColumns splits the rows of the docker-compose v1 ps table
+var legacyColumns = regexp.MustCompile(`\s{2,}`)
+
+// legacyPort matches a published port such as 0.0.0.0:8080->80/tcp
+var legacyPort = regexp.MustCompile(`(?:[\d.:\[\]]*:)?(\d+)->(\d+/\w+)`)
+
+// ParseContainers parses the table printed by docker-compose v1 ps:
+//
+//	Name         Command    State    Ports
+//	-----------------------------------------------------
+//	app_web_1    nginx      Up       0.0.0.0:8080->80/tcp
+//
+// Container names are <project>_<service>_<index> and v1 project names
+// contain no underscores, so the service is the middle part.
+func (dockerComposeBackend) ParseContainers(output []byte) ([]Container, error) {
+	var containers []Container
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	header := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if header {
+			// Everything up to the dashed separator is the header
+			header = !strings.HasPrefix(line, "---")
+			continue
+		}
+		if line == "" {
+			continue
+		}
+
+		columns := legacyColumns.Split(line, -1)
+		if len(columns) < 3 {
+			return nil, fmt.Errorf("error parsing container list: unexpected line '%s'", line)
+		}
+
+		name, status := columns[0], columns[2]
+		container := Container{Name: name, Status: status, State: "exited", Service: name}
+		if parts := strings.Split(name, "_"); len(parts) >= 3 {
+			container.Service = strings.Join(parts[1:len(parts)-1], "_")
+		}
+		if strings.HasPrefix(status, "Up") {
+			container.State = "running"
+		}
+		container.Health = containerHealth(status)
+		if len(columns) > 3 {
+			for _, match := range legacyPort.FindAllStringSubmatch(columns[3], -1) {
+				container.Ports = append(container.Ports, match[1]+"->"+match[2])
+			}
+		}
+		containers = append(containers, container)
+	}
+	return containers, scanner.Err()
+}
+
+// podmanBackend runs podman compose. The Docker context maps to a podman
+// connection and the Docker host to CONTAINER_HOST.
+type podmanBackend struct{}
+
+func (podmanBackend) Name() string { return BackendPodman }
+
+func (podmanBackend) VersionCommand() ComposeCommand {
+	return ComposeCommand{Program: "podman", Args: []string{"compose", "version"}}
+}
+
+func (podmanBackend) Command(project model.Project, args ...string) (ComposeCommand, error) {
+	var commandArgs []string
+	if project.DockerContext != "" {
+		commandArgs = append(commandArgs, "--connection", project.DockerContext)
+	}
+	commandArgs = append(commandArgs, "compose")
+	commandArgs = append(commandArgs, composeFlags(project)...)
+	return ComposeCommand{
+		Program: "podman",
+		Args:    append(commandArgs, args...),
+		Env:     projectEnv(project, "CONTAINER_HOST"),
+	}, nil
+}
+
+func (podmanBackend) ContainersArgs() []string {
+	return []string{"ps", "-a", "--format", "json"}
+}
+
+// podmanContainer is a single entry of `podman ps --format json`
+type podmanContainer struct {
+	Names  []string          `json:"Names"`
+	State  string            `json:"State"`
+	Status string            `json:"Status"`
+	Labels map[string]string `json:"Labels"`
+	Ports  []struct {
+		HostIP        string `json:"host_ip"`
+		ContainerPort int    `json:"container_port"`
+		HostPort      int    `json:"host_port"`
+		Protocol      string `json:"protocol"`
+	} `json:"Ports"`
+}
+
+// ParseContainers parses the podman ps array printed by podman-compose.
+// When podman compose delegates to docker compose, the output is the one
+// of the docker backend.
+func (podmanBackend) ParseContainers(output []byte) ([]Container, error) {
+	trimmed := bytes.TrimSpace(output)
+	if len(trimmed) == 0 || trimmed[0] != '[' || !bytes.Contains(trimmed, []byte(`"Labels"`)) {
+		return parseComposePS(output)
+	}
+
+	var podmanContainers []podmanContainer
+	if err := json.Unmarshal(trimmed, &podmanContainers); err != nil {
+		return nil, fmt.Errorf("error parsing container list: %w", err)
+	}
+
+	containers := make([]Container, 0, len(podmanContainers))
+	for _, c := range podmanContainers {
+		container := Container{
+			Service: c.Labels["com.docker.compose.service"],
+			State:   c.State,
+			Status:  c.Status,
+			Health:  containerHealth(c.Status),
+		}
+		if len(c.Names) > 0 {
+			container.Name = c.Names[0]
+		}
+		publishers := make([]composePublisher, 0, len(c.Ports))
+		for _, p := range c.Ports {
+			publishers = append(publishers, composePublisher{URL: p.HostIP, TargetPort: p.ContainerPort, PublishedPort: p.HostPort, Protocol: p.Protocol})
+		}
+		container.Ports = formatPorts(publishers)
+		containers = append(containers, container)
+	}
+	return containers, nil
+}
+
+// nerdctlBackend runs nerdctl compose, which talks to containerd rather
+// than a Docker daemon
+type nerdctlBackend struct{}
+
+func (nerdctlBackend) Name() string { return BackendNerdctl }
+
+func (nerdctlBackend) VersionCommand() ComposeCommand {
+	return ComposeCommand{Program: "nerdctl", Args: []string{"compose", "version"}}
+}
+
+func (nerdctlBackend) Command(project model.Project, args ...string) (ComposeCommand, error) {
+	if project.DockerContext != "" || project.DockerHost != "" {
+		return ComposeCommand{}, fmt.Errorf("the %s backend does not support docker_context or docker_host", BackendNerdctl)
+	}
+	commandArgs := append([]string{"compose"}, composeFlags(project)...)
+	return ComposeCommand{
+		Program: "nerdctl",
+		Args:    append(commandArgs, args...),
+		Env:     projectEnv(project, ""),
+	}, nil
+}
+
+func (nerdctlBackend) ContainersArgs() []string {
+	return []string{"ps", "-a", "--format", "json"}
+}
+
+func (nerdctlBackend) ParseContainers(output []byte) ([]Container, error) {
+	return parseComposePS(output)
+}
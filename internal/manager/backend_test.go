@@ -0,0 +1,251 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// fakeBinaries writes shell scripts named after their key and makes them
+// the only programs on PATH, so no real compose engine is ever run
+func fakeBinaries(t *testing.T, scripts map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestDetectBackend(t *testing.T) {
+	const installed = "exit 0\n"
+	// docker without the compose plugin
+	const noPlugin = "exit 1\n"
+
+	tests := []struct {
+		name    string
+		scripts map[string]string
+		want    string
+	}{
+		{"docker first", map[string]string{"docker": installed, "docker-compose": installed, "podman": installed}, BackendDocker},
+		{"docker without plugin", map[string]string{"docker": noPlugin, "docker-compose": installed}, BackendDockerCompose},
+		{"podman", map[string]string{"podman": installed, "nerdctl": installed}, BackendPodman},
+		{"nerdctl", map[string]string{"docker": noPlugin, "nerdctl": installed}, BackendNerdctl},
+		{"none", map[string]string{"docker": noPlugin}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeBinaries(t, tt.scripts)
+
+			backend, err := DetectBackend(&DefaultCommandExecutor{})
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("detected %s, want an error", backend.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if backend.Name() != tt.want {
+				t.Errorf("detected %s, want %s", backend.Name(), tt.want)
+			}
+		})
+	}
+}
+
+// echoArgs prints each argument on its own line, then the variables
+// selecting the engine, so tests see exactly what the binary received
+const echoArgs = `for arg in "$@"; do echo "$arg"; done
+echo "env A=$A DOCKER_HOST=$DOCKER_HOST CONTAINER_HOST=$CONTAINER_HOST"
+`
+
+func TestBackendCommand(t *testing.T) {
+	settings := model.ProjectSettings{
+		ComposeFiles: []string{"override.yaml"},
+		EnvFiles:     []string{".env.dev"},
+		Profiles:     []string{"dev", "debug"},
+		Env:          map[string]string{"B": "2", "A": "1"},
+	}
+	composeFlags := []string{"-f", "compose.yaml", "-f", "override.yaml", "--env-file", ".env.dev", "--profile", "dev", "--profile", "debug", "up", "-d"}
+
+	withContext := model.Project{Name: "api", File: "compose.yaml", ProjectSettings: settings}
+	withContext.DockerContext = "remote"
+	withHost := model.Project{Name: "api", File: "compose.yaml", ProjectSettings: settings}
+	withHost.DockerHost = "ssh://me@box"
+
+	tests := []struct {
+		backend string
+		project model.Project
+		want    []string
+		env     string
+	}{
+		{BackendDocker, withContext, append([]string{"--context", "remote", "compose"}, composeFlags...), "A=1 DOCKER_HOST= CONTAINER_HOST="},
+		{BackendDocker, withHost, append([]string{"compose"}, composeFlags...), "A=1 DOCKER_HOST=ssh://me@box CONTAINER_HOST="},
+		{BackendDockerCompose, withContext, append([]string{"--context", "remote"}, composeFlags...), "A=1 DOCKER_HOST= CONTAINER_HOST="},
+		{BackendDockerCompose, withHost, composeFlags, "A=1 DOCKER_HOST=ssh://me@box CONTAINER_HOST="},
+		{BackendPodman, withContext, append([]string{"--connection", "remote", "compose"}, composeFlags...), "A=1 DOCKER_HOST= CONTAINER_HOST="},
+		{BackendPodman, withHost, append([]string{"compose"}, composeFlags...), "A=1 DOCKER_HOST= CONTAINER_HOST=ssh://me@box"},
+		{BackendNerdctl, model.Project{Name: "api", File: "compose.yaml", ProjectSettings: settings}, append([]string{"compose"}, composeFlags...), "A=1 DOCKER_HOST= CONTAINER_HOST="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backend+"/"+tt.project.DockerTarget(), func(t *testing.T) {
+			fakeBinaries(t, map[string]string{"docker": echoArgs, "docker-compose": echoArgs, "podman": echoArgs, "nerdctl": echoArgs})
+			t.Setenv("DOCKER_HOST", "")
+			t.Setenv("CONTAINER_HOST", "")
+
+			backend, err := ParseBackend(tt.backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+			command, err := backend.Command(tt.project, "up", "-d")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if command.Program != tt.backend {
+				t.Errorf("program is %s, want %s", command.Program, tt.backend)
+			}
+			if !reflect.DeepEqual(command.Args, tt.want) {
+				t.Errorf("args are %q, want %q", command.Args, tt.want)
+			}
+
+			output, err := (&DefaultCommandExecutor{}).ExecuteEnv("", command.Env, command.Program, command.Args...)
+			if err != nil {
+				t.Fatalf("%v: %s", err, output)
+			}
+			want := strings.Join(tt.want, "\n") + "\nenv " + tt.env + "\n"
+			if string(output) != want {
+				t.Errorf("binary received:\n%s\nwant:\n%s", output, want)
+			}
+		})
+	}
+}
+
+func TestNerdctlRejectsDockerTarget(t *testing.T) {
+	for _, project := range []model.Project{
+		{Name: "api", ProjectSettings: model.ProjectSettings{DockerContext: "remote"}},
+		{Name: "api", ProjectSettings: model.ProjectSettings{DockerHost: "ssh://me@box"}},
+	} {
+		if _, err := (nerdctlBackend{}).Command(project, "up"); err == nil {
+			t.Errorf("no error for %s", project.DockerTarget())
+		}
+	}
+}
+
+// Container lists of a project with a running web service and an exited db
+// service, as printed by each backend
+const (
+	dockerPS = `{"Name":"api-web-1","Service":"web","State":"running","Status":"Up 2 minutes (healthy)","Health":"healthy","Publishers":[{"URL":"0.0.0.0","TargetPort":80,"PublishedPort":8080,"Protocol":"tcp"},{"URL":"::","TargetPort":80,"PublishedPort":8080,"Protocol":"tcp"}]}
+{"Name":"api-db-1","Service":"db","State":"exited","Status":"Exited (1) 1 minute ago","Health":"","Publishers":[]}`
+
+	legacyPS = `    Name                Command              State            Ports
+--------------------------------------------------------------------------------
+api_web_1   nginx -g daemon off;           Up (healthy)   0.0.0.0:8080->80/tcp
+api_db_1    docker-entrypoint.sh postgres   Exit 1`
+
+	podmanPS = `[{"Names":["api_web_1"],"State":"running","Status":"Up 2 minutes (healthy)","Labels":{"com.docker.compose.service":"web"},"Ports":[{"host_ip":"","container_port":80,"host_port":8080,"protocol":"tcp"}]},
+{"Names":["api_db_1"],"State":"exited","Status":"Exited (1) 1 minute ago","Labels":{"com.docker.compose.service":"db"},"Ports":[]}]`
+)
+
+func TestParseContainers(t *testing.T) {
+	web := func(name string) Container {
+		return Container{Name: name, Service: "web", State: "running", Status: "Up 2 minutes (healthy)", Health: "healthy", Ports: []string{"8080->80/tcp"}}
+	}
+	db := func(name, status string) Container {
+		return Container{Name: name, Service: "db", State: "exited", Status: status}
+	}
+
+	tests := []struct {
+		backend string
+		output  string
+		want    []Container
+	}{
+		{BackendDocker, dockerPS, []Container{web("api-web-1"), db("api-db-1", "Exited (1) 1 minute ago")}},
+		{BackendDockerCompose, legacyPS, []Container{
+			{Name: "api_web_1", Service: "web", State: "running", Status: "Up (healthy)", Health: "healthy", Ports: []string{"8080->80/tcp"}},
+			db("api_db_1", "Exit 1"),
+		}},
+		{BackendPodman, podmanPS, []Container{web("api_web_1"), db("api_db_1", "Exited (1) 1 minute ago")}},
+		// podman compose delegating to docker compose prints its output
+		{BackendPodman, dockerPS, []Container{web("api-web-1"), db("api-db-1", "Exited (1) 1 minute ago")}},
+		{BackendNerdctl, dockerPS, []Container{web("api-web-1"), db("api-db-1", "Exited (1) 1 minute ago")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			backend, _ := ParseBackend(tt.backend)
+			got, err := backend.ParseContainers([]byte(tt.output))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLegacyContainersRejectsGarbage(t *testing.T) {
+	output := "Name   Command   State   Ports\n-----\nnot-a-row\n"
+	if _, err := (dockerComposeBackend{}).ParseContainers([]byte(output)); err == nil {
+		t.Error("no error for a row without columns")
+	}
+}
+
+// fakeCompose answers version, ps and config --services like a compose
+// engine running the project of TestStatusWithDetectedBackend
+const fakeCompose = `case " $* " in
+*" version "*) exit 0 ;;
+*" config --services "*) echo web; echo db ;;
+*" ps "*) echo "$FAKE_PS" ;;
+*) exit 1 ;;
+esac
+`
+
+func TestStatusWithDetectedBackend(t *testing.T) {
+	tests := []struct {
+		program string
+		output  string
+	}{
+		{"docker", dockerPS},
+		{"docker-compose", legacyPS},
+		{"podman", podmanPS},
+		{"nerdctl", dockerPS},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.program, func(t *testing.T) {
+			fakeBinaries(t, map[string]string{tt.program: fakeCompose})
+			t.Setenv("FAKE_PS", tt.output)
+
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services: {}\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			status := NewManager(nil).GetProjectStatus(model.Project{Name: "api", Path: dir, File: "compose.yaml"})
+			if status.Error != nil {
+				t.Fatal(status.Error)
+			}
+			if !status.Running || len(status.Services) != 2 {
+				t.Fatalf("got %+v, want 2 services with web running", status)
+			}
+
+			db, web := status.Services[0], status.Services[1]
+			if !web.Running || web.Health != "healthy" || !reflect.DeepEqual(web.Ports, []string{"8080->80/tcp"}) {
+				t.Errorf("web is %+v", web)
+			}
+			if db.Running || !db.Exited || db.ExitCode != 1 {
+				t.Errorf("db is %+v", db)
+			}
+		})
+	}
+}
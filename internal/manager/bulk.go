@@ -0,0 +1,147 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/mitas/dcm/internal/config"
+	"github.com/mitas/dcm/internal/model"
+)
+
+// DefaultAliasTemplate names managed projects after their directory
+const DefaultAliasTemplate = "{{.Name}}"
+
+// aliasData is the data available to alias templates
+type aliasData struct {
+	Name      string
+	ParentDir string
+	Path      string
+	File      string
+}
+
+// AliasTemplate generates aliases for projects added in bulk
+type AliasTemplate struct {
+	tmpl *template.Template
+}
+
+// ParseAliasTemplate parses an alias template such as "{{.ParentDir}}-{{.Name}}"
+func ParseAliasTemplate(text string) (*AliasTemplate, error) {
+	if text == "" {
+		text = DefaultAliasTemplate
+	}
+	tmpl, err := template.New("alias").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing alias template: %w", err)
+	}
+	return &AliasTemplate{tmpl: tmpl}, nil
+}
+
+// Alias renders the alias of a project
+func (t *AliasTemplate) Alias(project model.Project) (string, error) {
+	var buf bytes.Buffer
+	err := t.tmpl.Execute(&buf, aliasData{
+		Name:      project.Name,
+		ParentDir: filepath.Base(filepath.Dir(project.Path)),
+		Path:      project.Path,
+		File:      project.File,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error executing alias template: %w", err)
+	}
+
+	alias := strings.TrimSpace(buf.String())
+	if alias == "" {
+		return "", fmt.Errorf("alias template produced an empty alias")
+	}
+	return alias, nil
+}
+
+// SelectProjects returns the projects matching any of the selectors, in
+// discovery order. A selector containing glob characters is matched against
+// the whole project name, any other selector as a case-insensitive substring.
+func (m *Manager) SelectProjects(projects []model.Project, selectors []string) ([]model.Project, error) {
+	var selected []model.Project
+	for _, project := range projects {
+		for _, selector := range selectors {
+			matched, err := matchSelector(selector, project.Name)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				selected = append(selected, project)
+				break
+			}
+		}
+	}
+	return selected, nil
+}
+
+// matchSelector reports whether a project name matches a selector
+func matchSelector(selector, name string) (bool, error) {
+	if strings.ContainsAny(selector, "*?[") {
+		matched, err := filepath.Match(strings.ToLower(selector), strings.ToLower(name))
+		if err != nil {
+			return false, fmt.Errorf("invalid selector '%s': %w", selector, err)
+		}
+		return matched, nil
+	}
+	return strings.Contains(strings.ToLower(name), strings.ToLower(selector)), nil
+}
+
+// AddManagedProjects adds several projects to the managed projects list.
+// Projects whose path is already managed are skipped, and alias conflicts
+// are reported per project instead of stopping the whole batch.
+func (m *Manager) AddManagedProjects(managedConfig *config.ManagedConfig, projects []model.Project, aliases *AliasTemplate) []model.Result {
+	results := make([]model.Result, 0, len(projects))
+
+	for _, project := range projects {
+		if existing, found := managedProjectByPath(managedConfig, project.Path); found {
+			results = append(results, model.Result{
+				Project: project,
+				Success: true,
+				Message: fmt.Sprintf("Skipped %s, already managed as '%s'", project.Path, existing.Alias),
+			})
+			continue
+		}
+
+		alias, err := aliases.Alias(project)
+		if err == nil {
+			if index := managedProjectIndex(managedConfig, alias); index != -1 {
+				err = fmt.Errorf("alias '%s' is already used by %s", alias, managedConfig.Projects[index].Project.Path)
+			}
+		}
+		if err != nil {
+			results = append(results, model.Result{
+				Project: project,
+				Success: false,
+				Error:   fmt.Errorf("could not add %s: %w", project.Path, err),
+			})
+			continue
+		}
+
+		managedConfig.Projects = append(managedConfig.Projects, model.ManagedProject{
+			Alias:   alias,
+			Project: project,
+		})
+		results = append(results, model.Result{
+			Project: project,
+			Success: true,
+			Message: fmt.Sprintf("Added '%s' as '%s'", project.Name, alias),
+		})
+	}
+
+	return results
+}
+
+// managedProjectByPath finds the managed project registered for a path
+func managedProjectByPath(managedConfig *config.ManagedConfig, path string) (model.ManagedProject, bool) {
+	for _, p := range managedConfig.Projects {
+		if p.Project.Path == path {
+			return p, true
+		}
+	}
+	return model.ManagedProject{}, false
+}
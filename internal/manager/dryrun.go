@@ -0,0 +1,129 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// readOnlySubcommands are the compose subcommands that only read state and
+// still run in dry-run mode, so status, detection and ordering stay accurate
+var readOnlySubcommands = map[string]bool{
+	"version": true,
+	"ps":      true,
+	"ls":      true,
+	"config":  true,
+	"logs":    true,
+	"images":  true,
+}
+
+// readOnlyPrograms are programs that never change state when dcm runs them
+var readOnlyPrograms = map[string]bool{
+	"git": true,
+}
+
+// valueFlags are the global compose and docker flags followed by a value
+var valueFlags = map[string]bool{
+	"-f":             true,
+	"--file":         true,
+	"--env-file":     true,
+	"--profile":      true,
+	"-p":             true,
+	"--project-name": true,
+	"--context":      true,
+	"--connection":   true,
+}
+
+// DryRunExecutor decorates a CommandExecutor, printing the commands that
+// change state instead of running them. Read-only commands still run.
+type DryRunExecutor struct {
+	next CommandExecutor
+	out  io.Writer
+	// mu keeps lines of projects handled at once from interleaving
+	mu sync.Mutex
+}
+
+// NewDryRunExecutor creates a dry-run decorator printing to out
+func NewDryRunExecutor(next CommandExecutor, out io.Writer) *DryRunExecutor {
+	return &DryRunExecutor{next: next, out: out}
+}
+
+// Execute prints the command, or runs it if it only reads state
+func (e *DryRunExecutor) Execute(dir string, command string, args ...string) ([]byte, error) {
+	return e.ExecuteEnv(dir, nil, command, args...)
+}
+
+// ExecuteEnv prints the command with its directory and environment, or
+// runs it if it only reads state
+func (e *DryRunExecutor) ExecuteEnv(dir string, env []string, command string, args ...string) ([]byte, error) {
+	if isReadOnly(command, args) {
+		return e.next.ExecuteEnv(dir, env, command, args...)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fmt.Fprintln(e.out, CommandLine(dir, env, command, args...))
+	return nil, nil
+}
+
+// isReadOnly reports whether a command only reads state
+func isReadOnly(command string, args []string) bool {
+	if readOnlyPrograms[command] {
+		return true
+	}
+	if command == "sh" {
+		// Hooks may do anything
+		return false
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case valueFlags[arg]:
+			i++
+		case strings.HasPrefix(arg, "-"), arg == "compose":
+		default:
+			return readOnlySubcommands[arg]
+		}
+	}
+	return false
+}
+
+// unquotedPattern matches words that need no quoting in a shell
+var unquotedPattern = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes a word for a POSIX shell when it needs it
+func shellQuote(word string) string {
+	if unquotedPattern.MatchString(word) {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// CommandLine renders a command as a line that can be pasted in a shell
+func CommandLine(dir string, env []string, command string, args ...string) string {
+	words := make([]string, 0, len(env)+len(args)+1)
+	for _, variable := range env {
+		key, value, _ := strings.Cut(variable, "=")
+		words = append(words, key+"="+shellQuote(value))
+	}
+	words = append(words, shellQuote(command))
+	for _, arg := range args {
+		words = append(words, shellQuote(arg))
+	}
+
+	line := strings.Join(words, " ")
+	if dir != "" {
+		line = "cd " + shellQuote(dir) + " && " + line
+	}
+	return line
+}
+
+// SetDryRun makes the manager print the commands that change state to out
+// instead of running them
+func (m *Manager) SetDryRun(out io.Writer) {
+	m.executor = NewDryRunExecutor(m.executor, out)
+	m.dryRun = true
+}
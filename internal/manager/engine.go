@@ -0,0 +1,190 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// Names of the status backends
+const (
+	// StatusCompose checks status by running the compose backend per project
+	StatusCompose = "compose"
+	// StatusEngine checks status through the Docker Engine API
+	StatusEngine = "engine"
+)
+
+// Labels docker compose sets on the containers it creates
+const (
+	composeProjectLabel    = "com.docker.compose.project"
+	composeServiceLabel    = "com.docker.compose.service"
+	composeWorkingDirLabel = "com.docker.compose.project.working_dir"
+)
+
+// defaultEngineSocket is the Docker Engine API socket used without DOCKER_HOST
+const defaultEngineSocket = "/var/run/docker.sock"
+
+// engineTimeout bounds a single request to the Docker Engine API
+const engineTimeout = 10 * time.Second
+
+// EngineContainer is a container as listed by the Docker Engine API
+type EngineContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	State  string            `json:"State"`
+	Status string            `json:"Status"`
+	Labels map[string]string `json:"Labels"`
+	Ports  []EnginePort      `json:"Ports"`
+}
+
+// EnginePort is a port of a container as listed by the Docker Engine API
+type EnginePort struct {
+	IP          string `json:"IP"`
+	PrivatePort int    `json:"PrivatePort"`
+	PublicPort  int    `json:"PublicPort"`
+	Type        string `json:"Type"`
+}
+
+// EngineClient talks to the Docker Engine API over a unix socket
+type EngineClient struct {
+	client *http.Client
+	// err is returned by every request when host is not supported
+	err error
+}
+
+// NewEngineClient creates a client for the Docker Engine API at host, a
+// unix:// address. An empty host uses DOCKER_HOST, then the default socket.
+// Requests fail when host is not a unix socket.
+func NewEngineClient(host string) *EngineClient {
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if host == "" {
+		host = "unix://" + defaultEngineSocket
+	}
+
+	socket, ok := strings.CutPrefix(host, "unix://")
+	if !ok {
+		return &EngineClient{err: fmt.Errorf("the %s status backend only supports unix sockets, not %s", StatusEngine, host)}
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		},
+	}
+	return &EngineClient{client: &http.Client{Transport: transport, Timeout: engineTimeout}}
+}
+
+// ComposeContainers lists every container created by docker compose,
+// including stopped ones
+func (c *EngineClient) ComposeContainers() ([]EngineContainer, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	filters := fmt.Sprintf(`{"label":[%q]}`, composeProjectLabel)
+	// The host is ignored, requests go to the socket
+	response, err := c.client.Get("http://docker/containers/json?all=1&filters=" + url.QueryEscape(filters))
+	if err != nil {
+		return nil, fmt.Errorf("error contacting the Docker engine: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return nil, fmt.Errorf("error listing containers: %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+
+	var containers []EngineContainer
+	if err := json.NewDecoder(response.Body).Decode(&containers); err != nil {
+		return nil, fmt.Errorf("error parsing container list: %w", err)
+	}
+	return containers, nil
+}
+
+// SetEngineClient makes status checks use the Docker Engine API, nil to
+// run the compose backend for every project
+func (m *Manager) SetEngineClient(client *EngineClient) {
+	m.engine = client
+}
+
+// composeNamePattern matches the characters compose drops from project names
+var composeNamePattern = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// composeProjectName returns the name compose gives a project by default
+func composeProjectName(project model.Project) string {
+	return composeNamePattern.ReplaceAllString(strings.ToLower(filepath.Base(canonicalPath(project.Path))), "")
+}
+
+// engineStatus builds the status of a resolved project from the containers
+// of the engine. Containers are matched by working directory, or by compose
+// project name when they have no working directory label. Only services
+// with a container are known without reading the compose file. Paths are
+// compared canonically as projects may be found under a relative --path.
+func engineStatus(project model.Project, engineContainers []EngineContainer) model.ProjectStatus {
+	projectDir := canonicalPath(project.Path)
+	projectName := composeProjectName(project)
+
+	var containers []Container
+	var services []string
+	seen := make(map[string]bool)
+
+	for _, c := range engineContainers {
+		if dir, ok := c.Labels[composeWorkingDirLabel]; ok {
+			if canonicalPath(dir) != projectDir {
+				continue
+			}
+		} else if c.Labels[composeProjectLabel] != projectName {
+			continue
+		}
+
+		container := Container{
+			Service: c.Labels[composeServiceLabel],
+			State:   c.State,
+			Status:  c.Status,
+			Health:  containerHealth(c.Status),
+		}
+		if len(c.Names) > 0 {
+			container.Name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		publishers := make([]composePublisher, 0, len(c.Ports))
+		for _, p := range c.Ports {
+			publishers = append(publishers, composePublisher{URL: p.IP, TargetPort: p.PrivatePort, PublishedPort: p.PublicPort, Protocol: p.Type})
+		}
+		container.Ports = formatPorts(publishers)
+		containers = append(containers, container)
+
+		if !seen[container.Service] {
+			seen[container.Service] = true
+			services = append(services, container.Service)
+		}
+	}
+
+	return buildStatus(project, services, containers)
+}
+
+// healthPattern matches the health check state in a container status,
+// e.g. "Up 5 minutes (healthy)"
+var healthPattern = regexp.MustCompile(`\((healthy|unhealthy|health: starting)\)`)
+
+// containerHealth returns the health check state in a container status
+func containerHealth(status string) string {
+	match := healthPattern.FindStringSubmatch(status)
+	if match == nil {
+		return ""
+	}
+	return strings.TrimPrefix(match[1], "health: ")
+}
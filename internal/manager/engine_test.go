@@ -0,0 +1,173 @@
+package manager
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// fakeEngine serves handler as a Docker Engine API on a unix socket and
+// returns a client for it
+func fakeEngine(t *testing.T, handler http.HandlerFunc) *EngineClient {
+	t.Helper()
+	// Socket paths are limited to about 100 bytes, shorter than some TempDirs
+	dir, err := os.MkdirTemp("", "dcm-engine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	return NewEngineClient("unix://" + socket)
+}
+
+// serveContainers answers container list requests with containers
+func serveContainers(t *testing.T, requests *int32, containers []EngineContainer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		if r.URL.Path != "/containers/json" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		if query.Get("all") != "1" || !strings.Contains(query.Get("filters"), composeProjectLabel) {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode(containers)
+	}
+}
+
+// engineContainer builds a container of a compose service in dir
+func engineContainer(dir, service, state, status string) EngineContainer {
+	return EngineContainer{
+		Names:  []string{"/" + filepath.Base(dir) + "-" + service + "-1"},
+		State:  state,
+		Status: status,
+		Labels: map[string]string{
+			composeProjectLabel:    filepath.Base(dir),
+			composeServiceLabel:    service,
+			composeWorkingDirLabel: dir,
+		},
+		Ports: []EnginePort{{IP: "0.0.0.0", PrivatePort: 80, PublicPort: 8080, Type: "tcp"}},
+	}
+}
+
+// composeProject creates a project directory with a compose file
+func composeProject(t *testing.T, dir string) model.Project {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return model.Project{Name: filepath.Base(dir), Path: dir, File: "compose.yaml"}
+}
+
+func TestEngineComposeContainers(t *testing.T) {
+	var requests int32
+	want := []EngineContainer{engineContainer("/srv/api", "web", "running", "Up 2 minutes (healthy)")}
+	client := fakeEngine(t, serveContainers(t, &requests, want))
+
+	got, err := client.ComposeContainers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Labels[composeServiceLabel] != "web" || got[0].Ports[0].PublicPort != 8080 {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestEngineErrors(t *testing.T) {
+	client := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "engine is sad", http.StatusInternalServerError)
+	})
+	if _, err := client.ComposeContainers(); err == nil || !strings.Contains(err.Error(), "engine is sad") {
+		t.Errorf("got %v, want the engine error", err)
+	}
+
+	if _, err := NewEngineClient("tcp://127.0.0.1:2375").ComposeContainers(); err == nil {
+		t.Error("no error for a tcp host")
+	}
+}
+
+func TestEngineStatusOneRequest(t *testing.T) {
+	root := t.TempDir()
+	api := composeProject(t, filepath.Join(root, "api"))
+	web := composeProject(t, filepath.Join(root, "web"))
+
+	var requests int32
+	containers := []EngineContainer{
+		engineContainer(api.Path, "app", "running", "Up 2 minutes (healthy)"),
+		engineContainer(api.Path, "migrate", "exited", "Exited (0) 1 minute ago"),
+		engineContainer(filepath.Join(root, "other"), "app", "running", "Up 1 minute"),
+	}
+	m := NewManager(nil)
+	m.SetEngineClient(fakeEngine(t, serveContainers(t, &requests, containers)))
+
+	statuses := m.GetProjectStatuses([]model.Project{api, web})
+	if requests != 1 {
+		t.Errorf("engine got %d requests, want 1", requests)
+	}
+
+	if statuses[0].Error != nil || !statuses[0].Running || len(statuses[0].Services) != 2 {
+		t.Fatalf("api status is %+v", statuses[0])
+	}
+	app := statuses[0].Services[0]
+	if app.Name != "app" || app.Health != "healthy" || len(app.Ports) != 1 || app.Ports[0] != "8080->80/tcp" {
+		t.Errorf("app is %+v", app)
+	}
+	if migrate := statuses[0].Services[1]; !migrate.Exited || migrate.ExitCode != 0 {
+		t.Errorf("migrate is %+v", migrate)
+	}
+
+	if statuses[1].Error != nil || statuses[1].Running || len(statuses[1].Services) != 0 {
+		t.Errorf("web status is %+v", statuses[1])
+	}
+}
+
+func TestEngineStatusRelativePath(t *testing.T) {
+	root := t.TempDir()
+	api := composeProject(t, filepath.Join(root, "api"))
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	// The label holds the absolute directory, resolved by compose
+	dir, err := filepath.EvalSymlinks(api.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests int32
+	m := NewManager(nil)
+	m.SetEngineClient(fakeEngine(t, serveContainers(t, &requests, []EngineContainer{engineContainer(dir, "app", "running", "Up 1 minute")})))
+
+	for _, path := range []string{"api", "./api", "api/."} {
+		api.Path = path
+		status := m.GetProjectStatus(api)
+		if status.Error != nil || !status.Running {
+			t.Errorf("project at %s: got %+v, want running", path, status)
+		}
+	}
+}
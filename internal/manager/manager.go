@@ -6,9 +6,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/mitas/dcm/internal/config"
 	"github.com/mitas/dcm/internal/model"
@@ -17,6 +17,9 @@ import (
 // CommandExecutor executes shell commands
 type CommandExecutor interface {
 	Execute(dir string, command string, args ...string) ([]byte, error)
+	// ExecuteEnv runs a command with extra KEY=value environment variables
+	// added to the environment of dcm
+	ExecuteEnv(dir string, env []string, command string, args ...string) ([]byte, error)
 }
 
 // DefaultCommandExecutor is the default implementation of CommandExecutor
@@ -24,14 +27,45 @@ type DefaultCommandExecutor struct{}
 
 // Execute runs a command and returns its output
 func (e *DefaultCommandExecutor) Execute(dir string, command string, args ...string) ([]byte, error) {
+	return e.ExecuteEnv(dir, nil, command, args...)
+}
+
+// ExecuteEnv runs a command with extra environment variables and returns its output
+func (e *DefaultCommandExecutor) ExecuteEnv(dir string, env []string, command string, args ...string) ([]byte, error) {
 	cmd := exec.Command(command, args...)
 	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	return cmd.CombinedOutput()
 }
 
 // Manager handles docker-compose operations
 type Manager struct {
 	executor CommandExecutor
+	// parallelism limits how many projects bulk actions handle at once,
+	// 0 means no limit
+	parallelism int
+	// backend runs compose for projects without their own backend, nil
+	// to detect it on first use
+	backend Backend
+	// engine lists containers for status checks, nil to use the backend
+	engine *EngineClient
+	// waitTimeout bounds waiting for services to become ready after a
+	// start, 0 means not waiting
+	waitTimeout time.Duration
+	// retries is how many times transient compose up and down failures
+	// are retried, waiting retryBackoff before the first retry
+	retries      int
+	retryBackoff time.Duration
+	// dryRun reports that commands changing state are printed, not run
+	dryRun bool
+	// store reads the .dcm.yaml of projects
+	store *config.Store
+
+	detectOnce sync.Once
+	detected   Backend
+	detectErr  error
 }
 
 // NewManager creates a new manager
@@ -41,9 +75,28 @@ func NewManager(executor CommandExecutor) *Manager {
 	}
 	return &Manager{
 		executor: executor,
+		store:    config.NewStore(os.Stderr),
 	}
 }
 
+// SetStore selects the store reading the .dcm.yaml of projects, so its
+// warnings go where the other config warnings go
+func (m *Manager) SetStore(store *config.Store) {
+	m.store = store
+}
+
+// SetParallelism limits how many projects bulk actions handle at once,
+// 0 means no limit
+func (m *Manager) SetParallelism(parallelism int) {
+	m.parallelism = parallelism
+}
+
+// SetBackend selects the compose backend of projects without their own,
+// nil to detect it on first use
+func (m *Manager) SetBackend(backend Backend) {
+	m.backend = backend
+}
+
 // FindProjects searches for docker-compose projects in the given path
 func (m *Manager) FindProjects(rootPath string) ([]model.Project, error) {
 	var projects []model.Project
@@ -54,19 +107,27 @@ func (m *Manager) FindProjects(rootPath string) ([]model.Project, error) {
 			return nil
 		}
 
+		if !info.IsDir() {
+			return nil
+		}
+
 		// Skip directories that start with . (hidden directories) or # (temp files)
-		if info.IsDir() && (strings.HasPrefix(info.Name(), ".") || strings.HasPrefix(info.Name(), "#")) {
+		if strings.HasPrefix(info.Name(), ".") || strings.HasPrefix(info.Name(), "#") {
 			return filepath.SkipDir
 		}
 
-		// Check if the file is a docker-compose file
-		filename := info.Name()
-		if !info.IsDir() && (filename == "docker-compose.yml" || filename == "docker-compose.yaml") {
-			dirPath := filepath.Dir(path)
-			projectName := filepath.Base(dirPath)
+		// Pick the compose file docker compose would use, so a directory
+		// with several compose files is a single project
+		filename := config.FindComposeFile(path)
+		if filename != "" {
+			projectName := filepath.Base(path)
+			// A .dcm.yaml next to the compose file may rename the project
+			if local, err := m.store.LoadLocalConfig(path); err == nil && local != nil && local.Name != "" {
+				projectName = local.Name
+			}
 			projects = append(projects, model.Project{
 				Name: projectName,
-				Path: dirPath,
+				Path: path,
 				File: filename,
 			})
 		}
@@ -76,92 +137,152 @@ func (m *Manager) FindProjects(rootPath string) ([]model.Project, error) {
 	return projects, err
 }
 
-// StartProject starts a docker-compose project
-func (m *Manager) StartProject(project model.Project) model.Result {
-	output, err := m.executor.Execute(project.Path, "docker", "compose", "up", "-d")
+// projectBackend returns the compose backend of a resolved project: its
+// own, then the default one
+func (m *Manager) projectBackend(project model.Project) (Backend, error) {
+	backend, err := ParseBackend(project.ComposeBackend)
+	if err != nil || backend != nil {
+		return backend, err
+	}
+	return m.defaultBackend()
+}
+
+// defaultBackend returns the backend set with SetBackend, or the detected one
+func (m *Manager) defaultBackend() (Backend, error) {
+	if m.backend != nil {
+		return m.backend, nil
+	}
+	m.detectOnce.Do(func() {
+		m.detected, m.detectErr = DetectBackend(m.executor)
+	})
+	return m.detected, m.detectErr
+}
+
+// compose runs a compose subcommand for a resolved project with its backend
+func (m *Manager) compose(project model.Project, args ...string) ([]byte, error) {
+	backend, err := m.projectBackend(project)
 	if err != nil {
-		return model.Result{
-			Project: project,
-			Success: false,
-			Error:   fmt.Errorf("error starting %s: %w: %s", project.Name, err, output),
-		}
+		return nil, err
 	}
-	return model.Result{
-		Project: project,
-		Success: true,
-		Message: fmt.Sprintf("Successfully started %s", project.Name),
+	command, err := backend.Command(project, args...)
+	if err != nil {
+		return nil, err
 	}
+	return m.executor.ExecuteEnv(project.Path, command.Env, command.Program, command.Args...)
 }
 
-// StopProject stops a docker-compose project
-func (m *Manager) StopProject(project model.Project) model.Result {
-	output, err := m.executor.Execute(project.Path, "docker", "compose", "down")
+// StartProject starts a docker-compose project, running its start hooks.
+// Retries of transient failures and waiting for services stop when ctx
+// is done.
+func (m *Manager) StartProject(ctx context.Context, project model.Project) model.Result {
+	project, err := m.ResolveProject(project)
+	if err == nil {
+		err = m.runHooks(project, "pre_start", project.Hooks.PreStart)
+	}
 	if err != nil {
 		return model.Result{
 			Project: project,
 			Success: false,
-			Error:   fmt.Errorf("error stopping %s: %w: %s", project.Name, err, output),
+			Error:   fmt.Errorf("error starting %s: %w", project.Name, err),
 		}
 	}
-	return model.Result{
-		Project: project,
-		Success: true,
-		Message: fmt.Sprintf("Successfully stopped %s", project.Name),
-	}
-}
 
-// CheckProjectStatus checks the status of a docker-compose project
-func (m *Manager) CheckProjectStatus(project model.Project) (bool, map[string]string, error) {
-	// Check if any containers exist
-	output, err := m.executor.Execute(project.Path, "docker", "compose", "ps", "-a", "--format", "json")
+	output, attempts, err := m.composeWithRetry(ctx, project, "up", "-d")
 	if err != nil {
-		return false, nil, fmt.Errorf("error checking status: %w", err)
+		return model.Result{
+			Project:  project,
+			Success:  false,
+			Error:    fmt.Errorf("error starting %s%s: %w: %s", project.Name, attemptsSuffix(attempts), err, output),
+			Attempts: attempts,
+		}
 	}
 
-	// If no output or just whitespace, project is not running
-	if len(strings.TrimSpace(string(output))) == 0 || string(output) == "[]" {
-		return false, nil, nil
+	if m.waitTimeout > 0 && !m.dryRun {
+		if err := m.waitForProject(ctx, project, m.waitTimeout); err != nil {
+			return model.Result{
+				Project:  project,
+				Success:  false,
+				Error:    fmt.Errorf("%s started but %w", project.Name, err),
+				Attempts: attempts,
+			}
+		}
 	}
 
-	// Get services from docker-compose.yml
-	servicesOutput, err := m.executor.Execute(project.Path, "docker", "compose", "config", "--services")
-	if err != nil {
-		return false, nil, fmt.Errorf("error getting services: %w", err)
+	if err := m.runHooks(project, "post_start", project.Hooks.PostStart); err != nil {
+		return model.Result{
+			Project:  project,
+			Success:  false,
+			Error:    fmt.Errorf("%s started but %w", project.Name, err),
+			Attempts: attempts,
+		}
 	}
-
-	services := strings.Split(strings.TrimSpace(string(servicesOutput)), "\n")
-	if len(services) == 0 || (len(services) == 1 && services[0] == "") {
-		return false, nil, nil
+	message := fmt.Sprintf("Successfully started %s%s", project.Name, attemptsSuffix(attempts))
+	switch {
+	case m.dryRun:
+		message = fmt.Sprintf("Would start %s (dry run)", project.Name)
+	case m.waitTimeout > 0:
+		message += ", all services are ready"
+	}
+	return model.Result{
+		Project:  project,
+		Success:  true,
+		Message:  message,
+		Attempts: attempts,
 	}
+}
 
-	// Get status for each service
-	serviceStatus := make(map[string]string)
-	isRunning := false
+// stoppedMessage describes a successful stop
+func (m *Manager) stoppedMessage(name string, attempts int) string {
+	if m.dryRun {
+		return fmt.Sprintf("Would stop %s (dry run)", name)
+	}
+	return fmt.Sprintf("Successfully stopped %s%s", name, attemptsSuffix(attempts))
+}
 
-	for _, service := range services {
-		if service == "" {
-			continue
+// StopProject stops a docker-compose project, running its stop hooks.
+// Retries of transient failures stop when ctx is done.
+func (m *Manager) StopProject(ctx context.Context, project model.Project) model.Result {
+	project, err := m.ResolveProject(project)
+	if err == nil {
+		err = m.runHooks(project, "pre_stop", project.Hooks.PreStop)
+	}
+	if err != nil {
+		return model.Result{
+			Project: project,
+			Success: false,
+			Error:   fmt.Errorf("error stopping %s: %w", project.Name, err),
 		}
+	}
 
-		statusOutput, err := m.executor.Execute(project.Path, "docker", "compose", "ps", service, "--format", "{{.Status}}")
-		if err != nil || strings.TrimSpace(string(statusOutput)) == "" {
-			serviceStatus[service] = "not running"
-			continue
+	output, attempts, err := m.composeWithRetry(ctx, project, "down")
+	if err != nil {
+		return model.Result{
+			Project:  project,
+			Success:  false,
+			Error:    fmt.Errorf("error stopping %s%s: %w: %s", project.Name, attemptsSuffix(attempts), err, output),
+			Attempts: attempts,
 		}
+	}
 
-		status := strings.TrimSpace(string(statusOutput))
-		serviceStatus[service] = status
-
-		// Check if service is running
-		if regexp.MustCompile(`(?i)up|running`).MatchString(status) {
-			isRunning = true
+	if err := m.runHooks(project, "post_stop", project.Hooks.PostStop); err != nil {
+		return model.Result{
+			Project:  project,
+			Success:  false,
+			Error:    fmt.Errorf("%s stopped but %w", project.Name, err),
+			Attempts: attempts,
 		}
 	}
-
-	return isRunning, serviceStatus, nil
+	return model.Result{
+		Project:  project,
+		Success:  true,
+		Message:  m.stoppedMessage(project.Name, attempts),
+		Attempts: attempts,
+	}
 }
 
-// ManageAllProjects executes an action on all projects concurrently
+// ManageAllProjects executes an action on all projects concurrently. A
+// project is started after the projects it depends on and stopped before
+// them; dependents of a project that failed to start are not started.
 func (m *Manager) ManageAllProjects(ctx context.Context, projects []model.Project, action model.ActionType) []model.Result {
 	if len(projects) == 0 {
 		return nil
@@ -171,11 +292,58 @@ func (m *Manager) ManageAllProjects(ctx context.Context, projects []model.Projec
 	resultCh := make(chan model.Result, len(projects))
 	results := make([]model.Result, 0, len(projects))
 
-	// Process each project in a goroutine
-	for _, project := range projects {
+	projects, before, cyclic := m.dependencyOrder(projects, action == model.ActionStop)
+	verb := "starting"
+	if action == model.ActionStop {
+		verb = "stopping"
+	}
+	for _, p := range cyclic {
+		resultCh <- model.Result{
+			Project: p,
+			Success: false,
+			Error:   fmt.Errorf("error %s %s: dependency cycle in depends_on", verb, p.Name),
+		}
+	}
+
+	// done[i] is closed once projects[i] was handled, succeeded[i] tells how
+	done := make([]chan struct{}, len(projects))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	succeeded := make([]bool, len(projects))
+
+	// Limit the number of projects handled at once
+	slots := len(projects)
+	if m.parallelism > 0 && m.parallelism < slots {
+		slots = m.parallelism
+	}
+	sem := make(chan struct{}, slots)
+
+	// Process each project in a goroutine, taking a slot before starting it
+	// so projects are handled in order when there is a single slot. The
+	// projects waited for come earlier, so they already hold a slot.
+	for i, project := range projects {
 		wg.Add(1)
-		go func(p model.Project) {
+		sem <- struct{}{}
+		go func(i int, p model.Project) {
 			defer wg.Done()
+			defer func() { <-sem }()
+			defer close(done[i])
+
+			for _, j := range before[i] {
+				select {
+				case <-ctx.Done():
+				case <-done[j]:
+					if action == model.ActionStart && !succeeded[j] {
+						resultCh <- model.Result{
+							Project: p,
+							Success: false,
+							Error:   fmt.Errorf("error starting %s: dependency %s failed to start", p.Name, projects[j].Name),
+						}
+						return
+					}
+				}
+			}
 
 			select {
 			case <-ctx.Done():
@@ -189,13 +357,14 @@ func (m *Manager) ManageAllProjects(ctx context.Context, projects []model.Projec
 				var result model.Result
 				switch action {
 				case model.ActionStart:
-					result = m.StartProject(p)
+					result = m.StartProject(ctx, p)
 				case model.ActionStop:
-					result = m.StopProject(p)
+					result = m.StopProject(ctx, p)
 				}
+				succeeded[i] = result.Success
 				resultCh <- result
 			}
-		}(project)
+		}(i, project)
 	}
 
 	// Close the channel when all goroutines are done
@@ -227,12 +396,12 @@ func (m *Manager) AddManagedProject(managedConfig *config.ManagedConfig, project
 	// Check if alias already exists
 	for _, p := range managedConfig.Projects {
 		if p.Alias == alias {
-			return fmt.Errorf("❌ Project with alias '%s' already exists in managed projects", alias)
+			return fmt.Errorf("project with alias '%s' already exists in managed projects", alias)
 		}
 
 		// Check if the project path already exists
 		if p.Project.Path == project.Path {
-			return fmt.Errorf("❌ Project at path '%s' already exists in managed projects with alias '%s'", project.Path, p.Alias)
+			return fmt.Errorf("project at path '%s' already exists in managed projects with alias '%s'", project.Path, p.Alias)
 		}
 	}
 
@@ -245,25 +414,178 @@ func (m *Manager) AddManagedProject(managedConfig *config.ManagedConfig, project
 	return nil
 }
 
-// RemoveManagedProject removes a project from the managed projects list
-func (m *Manager) RemoveManagedProject(managedConfig *config.ManagedConfig, alias string) error {
+// RemoveManagedProject removes a project from the managed projects list and returns it
+func (m *Manager) RemoveManagedProject(managedConfig *config.ManagedConfig, alias string) (model.ManagedProject, error) {
 	for i, p := range managedConfig.Projects {
 		if p.Alias == alias {
 			// Remove project at index i
 			managedConfig.Projects = append(managedConfig.Projects[:i], managedConfig.Projects[i+1:]...)
-			return nil
+			return p, nil
 		}
 	}
 
-	return fmt.Errorf("no project found with alias '%s'", alias)
+	return model.ManagedProject{}, fmt.Errorf("no project found with alias '%s'", alias)
 }
 
-// FindManagedProject finds a managed project by alias
-func (m *Manager) FindManagedProject(managedConfig *config.ManagedConfig, alias string) (model.ManagedProject, bool) {
-	for _, p := range managedConfig.Projects {
-		if strings.EqualFold(p.Alias, alias) || strings.Contains(strings.ToLower(p.Alias), strings.ToLower(alias)) {
+// GetManagedProject finds the managed project with exactly the given alias
+func (m *Manager) GetManagedProject(managedConfig *config.ManagedConfig, alias string) (model.ManagedProject, bool) {
+	if index := managedProjectIndex(managedConfig, alias); index != -1 {
+		return managedConfig.Projects[index], true
+	}
+	return model.ManagedProject{}, false
+}
+
+// ProtectedProject finds the protected managed project in the same
+// directory as project, if any
+func (m *Manager) ProtectedProject(managed []model.ManagedProject, project model.Project) (model.ManagedProject, bool) {
+	dir := canonicalPath(project.Path)
+	for _, p := range managed {
+		if p.Protected && canonicalPath(p.Project.Path) == dir {
 			return p, true
 		}
 	}
 	return model.ManagedProject{}, false
 }
+
+// PruneManagedProjects removes managed projects whose directory or compose
+// file no longer exists and returns them with the reason they were removed.
+// Protected projects are kept and reported as failures unless force is set.
+func (m *Manager) PruneManagedProjects(managedConfig *config.ManagedConfig, force bool) []model.Result {
+	var pruned []model.Result
+	kept := managedConfig.Projects[:0]
+
+	for _, p := range managedConfig.Projects {
+		problem := config.CheckProjectFiles(p.Project)
+		if problem == "" {
+			kept = append(kept, p)
+			continue
+		}
+		if p.Protected && !force {
+			kept = append(kept, p)
+			pruned = append(pruned, model.Result{
+				Project: p.Project,
+				Error:   fmt.Errorf("'%s' is protected, use --force to prune it: %s", p.Alias, problem),
+			})
+			continue
+		}
+		pruned = append(pruned, model.Result{
+			Project: p.Project,
+			Success: true,
+			Message: fmt.Sprintf("Pruned '%s': %s", p.Alias, problem),
+		})
+	}
+
+	managedConfig.Projects = kept
+	return pruned
+}
+
+// FindMovedProject locates a project that is no longer at its recorded path.
+// If dir itself holds a compose file it is used directly, otherwise dir is
+// scanned for a project with the same name.
+func (m *Manager) FindMovedProject(project model.Project, dir string) (model.Project, error) {
+	if _, err := os.Stat(filepath.Join(dir, project.File)); project.File != "" && err == nil {
+		return model.Project{Name: project.Name, Path: dir, File: project.File}, nil
+	}
+	if file := config.FindComposeFile(dir); file != "" {
+		return model.Project{Name: project.Name, Path: dir, File: file}, nil
+	}
+
+	projects, err := m.FindProjects(dir)
+	if err != nil {
+		return model.Project{}, fmt.Errorf("error finding projects: %w", err)
+	}
+
+	var matches []model.Project
+	for _, p := range projects {
+		if p.Name == project.Name {
+			matches = append(matches, p)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return model.Project{}, fmt.Errorf("no project named '%s' found in %s", project.Name, dir)
+	case 1:
+		return matches[0], nil
+	default:
+		paths := make([]string, 0, len(matches))
+		for _, p := range matches {
+			paths = append(paths, p.Path)
+		}
+		return model.Project{}, fmt.Errorf("several projects named '%s' found in %s: %s", project.Name, dir, strings.Join(paths, ", "))
+	}
+}
+
+// managedProjectIndex returns the index of the managed project with exactly
+// the given alias, or -1
+func managedProjectIndex(managedConfig *config.ManagedConfig, alias string) int {
+	for i, p := range managedConfig.Projects {
+		if p.Alias == alias {
+			return i
+		}
+	}
+	return -1
+}
+
+// RenameManagedProject changes the alias of a managed project, keeping everything else
+func (m *Manager) RenameManagedProject(managedConfig *config.ManagedConfig, oldAlias, newAlias string) (model.ManagedProject, error) {
+	if newAlias == "" {
+		return model.ManagedProject{}, fmt.Errorf("new alias is empty")
+	}
+
+	index := managedProjectIndex(managedConfig, oldAlias)
+	if index == -1 {
+		return model.ManagedProject{}, fmt.Errorf("no project found with alias '%s'", oldAlias)
+	}
+
+	for i, p := range managedConfig.Projects {
+		if i != index && p.Alias == newAlias {
+			return model.ManagedProject{}, fmt.Errorf("project with alias '%s' already exists in managed projects", newAlias)
+		}
+	}
+
+	managedConfig.Projects[index].Alias = newAlias
+	return managedConfig.Projects[index], nil
+}
+
+// UpdateManagedProject replaces the project a managed alias points to
+func (m *Manager) UpdateManagedProject(managedConfig *config.ManagedConfig, alias string, project model.Project) (model.ManagedProject, error) {
+	index := managedProjectIndex(managedConfig, alias)
+	if index == -1 {
+		return model.ManagedProject{}, fmt.Errorf("no project found with alias '%s'", alias)
+	}
+
+	for i, p := range managedConfig.Projects {
+		if i != index && p.Project.Path == project.Path {
+			return model.ManagedProject{}, fmt.Errorf("project at path '%s' already exists in managed projects with alias '%s'", project.Path, p.Alias)
+		}
+	}
+
+	managedConfig.Projects[index].Project = project
+	return managedConfig.Projects[index], nil
+}
+
+// SetManagedProjectProtected marks a managed project as protected or not
+func (m *Manager) SetManagedProjectProtected(managedConfig *config.ManagedConfig, alias string, protected bool) (model.ManagedProject, error) {
+	index := managedProjectIndex(managedConfig, alias)
+	if index == -1 {
+		return model.ManagedProject{}, fmt.Errorf("no project found with alias '%s'", alias)
+	}
+
+	managedConfig.Projects[index].Protected = protected
+	return managedConfig.Projects[index], nil
+}
+
+// RelocateManagedProject points a managed project at a new location
+func (m *Manager) RelocateManagedProject(managedConfig *config.ManagedConfig, alias string, project model.Project) (model.ManagedProject, error) {
+	index := managedProjectIndex(managedConfig, alias)
+	if index == -1 {
+		return model.ManagedProject{}, fmt.Errorf("no project found with alias '%s'", alias)
+	}
+
+	// Only the location changes, any other settings of the project are kept
+	relocated := managedConfig.Projects[index].Project
+	relocated.Path = project.Path
+	relocated.File = project.File
+	return m.UpdateManagedProject(managedConfig, alias, relocated)
+}
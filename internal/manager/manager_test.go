@@ -0,0 +1,45 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindProjects(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		"api/compose.yaml",
+		"web/docker-compose.yml",
+		"legacy/docker-compose.yaml",
+		// docker compose prefers compose.yml, it is a single project
+		"both/compose.yml",
+		"both/docker-compose.yml",
+		".hidden/compose.yaml",
+		"notes/README.md",
+	}
+	for _, file := range files {
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("services: {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	projects, err := NewManager(nil).FindProjects(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"api": "compose.yaml", "web": "docker-compose.yml", "legacy": "docker-compose.yaml", "both": "compose.yml"}
+	if len(projects) != len(want) {
+		t.Fatalf("found %+v, want %v", projects, want)
+	}
+	for _, p := range projects {
+		if want[p.Name] != p.File || p.Path != filepath.Join(root, p.Name) {
+			t.Errorf("found %+v, want file %s in %s", p, want[p.Name], filepath.Join(root, p.Name))
+		}
+	}
+}
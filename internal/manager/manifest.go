@@ -0,0 +1,218 @@
+package manager
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitas/dcm/internal/config"
+	"github.com/mitas/dcm/internal/model"
+)
+
+// ConflictStrategy decides what happens when an imported project clashes
+// with a managed project that has the same alias or path
+type ConflictStrategy string
+
+const (
+	// ConflictSkip keeps the managed project and ignores the imported one
+	ConflictSkip ConflictStrategy = "skip"
+	// ConflictOverwrite replaces the managed project with the imported one
+	ConflictOverwrite ConflictStrategy = "overwrite"
+	// ConflictRename imports the project under a free alias such as api-2
+	ConflictRename ConflictStrategy = "rename"
+)
+
+// ParseConflictStrategy validates a conflict strategy name
+func ParseConflictStrategy(name string) (ConflictStrategy, error) {
+	switch strategy := ConflictStrategy(name); strategy {
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown conflict strategy '%s' (valid strategies: skip, overwrite, rename)", name)
+}
+
+// ExportManifest converts the managed projects below root into a manifest
+// with relative paths. Projects outside root are not exported and are
+// returned as warnings. With gitRemotes, the URL of each project's origin
+// remote is recorded when it has one.
+func (m *Manager) ExportManifest(managedConfig *config.ManagedConfig, root, workspace string, gitRemotes bool) (*model.Manifest, []string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error resolving root: %w", err)
+	}
+
+	manifest := &model.Manifest{
+		Workspace: workspace,
+		Projects:  []model.ManifestProject{},
+	}
+	var warnings []string
+
+	for _, p := range managedConfig.Projects {
+		rel, err := filepath.Rel(root, p.Project.Path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			warnings = append(warnings, fmt.Sprintf("'%s' is not exported, %s is outside %s", p.Alias, p.Project.Path, root))
+			continue
+		}
+
+		entry := model.ManifestProject{
+			Alias:    p.Alias,
+			Name:     p.Project.Name,
+			Path:     filepath.ToSlash(rel),
+			File:     p.Project.File,
+			EnvFiles: p.Project.EnvFiles,
+		}
+		if gitRemotes {
+			entry.GitRemote = m.gitRemote(p.Project.Path)
+		}
+		manifest.Projects = append(manifest.Projects, entry)
+	}
+
+	return manifest, warnings, nil
+}
+
+// gitRemote returns the origin URL of the repository containing dir, or an
+// empty string if there is none
+func (m *Manager) gitRemote(dir string) string {
+	output, err := m.executor.Execute(dir, "git", "remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// ImportManifest resolves the manifest paths against root and merges the
+// projects into the managed config. Projects missing on disk are reported
+// as failures, conflicts are resolved with strategy. Protected projects are
+// only overwritten with force.
+func (m *Manager) ImportManifest(managedConfig *config.ManagedConfig, manifest *model.Manifest, root string, strategy ConflictStrategy, force bool) ([]model.Result, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving root: %w", err)
+	}
+
+	results := make([]model.Result, 0, len(manifest.Projects))
+	for _, entry := range manifest.Projects {
+		results = append(results, m.importProject(managedConfig, entry, root, strategy, force))
+	}
+	return results, nil
+}
+
+// importProject merges a single manifest entry into the managed config
+func (m *Manager) importProject(managedConfig *config.ManagedConfig, entry model.ManifestProject, root string, strategy ConflictStrategy, force bool) model.Result {
+	project := model.Project{
+		Name: entry.Name,
+		Path: filepath.Join(root, filepath.FromSlash(entry.Path)),
+		File: entry.File,
+	}
+	project.EnvFiles = entry.EnvFiles
+	if project.Name == "" {
+		project.Name = filepath.Base(project.Path)
+	}
+	failed := func(err error) model.Result {
+		return model.Result{Project: project, Success: false, Error: fmt.Errorf("could not import '%s': %w", entry.Alias, err)}
+	}
+
+	rel := filepath.Clean(filepath.FromSlash(entry.Path))
+	if entry.Alias == "" || filepath.IsAbs(entry.Path) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return failed(fmt.Errorf("entry needs an alias and a path inside the workspace root"))
+	}
+	if problem := config.CheckProjectFiles(project); problem != "" {
+		if entry.GitRemote != "" {
+			problem += fmt.Sprintf(", clone it with: git clone %s %s", entry.GitRemote, project.Path)
+		}
+		return failed(fmt.Errorf("%s", problem))
+	}
+
+	alias := entry.Alias
+	aliasIndex := managedProjectIndex(managedConfig, alias)
+	existing, pathManaged := managedProjectByPath(managedConfig, project.Path)
+	if strategy == ConflictOverwrite && !force {
+		if protected, ok := protectedConflict(managedConfig, alias, project.Path); ok {
+			return failed(fmt.Errorf("managed project '%s' is protected, use --force to overwrite it", protected.Alias))
+		}
+	}
+
+	if aliasIndex != -1 && pathManaged && existing.Alias == alias {
+		if strategy != ConflictOverwrite {
+			return model.Result{Project: project, Success: true, Message: fmt.Sprintf("Skipped '%s', already managed", alias)}
+		}
+		managedConfig.Projects[aliasIndex] = overwriteProject(managedConfig.Projects[aliasIndex], alias, project)
+		return model.Result{Project: project, Success: true, Message: fmt.Sprintf("Updated '%s'", alias)}
+	}
+
+	if aliasIndex != -1 || pathManaged {
+		switch strategy {
+		case ConflictSkip:
+			return model.Result{Project: project, Success: true, Message: fmt.Sprintf("Skipped '%s', %s", alias, describeConflict(managedConfig, alias, project.Path))}
+		case ConflictRename:
+			if pathManaged {
+				return model.Result{Project: project, Success: true, Message: fmt.Sprintf("Skipped '%s', %s is already managed as '%s'", alias, project.Path, existing.Alias)}
+			}
+			alias = freeAlias(managedConfig, alias)
+		case ConflictOverwrite:
+			// Keep the settings of the project using the alias, else the path
+			if aliasIndex != -1 {
+				existing = managedConfig.Projects[aliasIndex]
+			}
+			removeManagedProjects(managedConfig, alias, project.Path)
+			managedConfig.Projects = append(managedConfig.Projects, overwriteProject(existing, alias, project))
+			return model.Result{Project: project, Success: true, Message: fmt.Sprintf("Overwrote '%s' with %s", alias, project.Path)}
+		}
+	}
+
+	managedConfig.Projects = append(managedConfig.Projects, model.ManagedProject{Alias: alias, Project: project})
+	return model.Result{Project: project, Success: true, Message: fmt.Sprintf("Imported '%s' from %s", alias, project.Path)}
+}
+
+// overwriteProject replaces the fields of existing that a manifest carries,
+// keeping its local settings such as profiles, hooks and protection
+func overwriteProject(existing model.ManagedProject, alias string, project model.Project) model.ManagedProject {
+	existing.Alias = alias
+	existing.Project.Name = project.Name
+	existing.Project.Path = project.Path
+	existing.Project.File = project.File
+	if len(project.EnvFiles) > 0 {
+		existing.Project.EnvFiles = project.EnvFiles
+	}
+	return existing
+}
+
+// describeConflict explains why an imported project clashes with the config
+func describeConflict(managedConfig *config.ManagedConfig, alias, path string) string {
+	if index := managedProjectIndex(managedConfig, alias); index != -1 {
+		return fmt.Sprintf("alias is already used by %s", managedConfig.Projects[index].Project.Path)
+	}
+	existing, _ := managedProjectByPath(managedConfig, path)
+	return fmt.Sprintf("%s is already managed as '%s'", path, existing.Alias)
+}
+
+// freeAlias returns alias with the lowest numeric suffix not yet in use
+func freeAlias(managedConfig *config.ManagedConfig, alias string) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s-%d", alias, n)
+		if managedProjectIndex(managedConfig, candidate) == -1 {
+			return candidate
+		}
+	}
+}
+
+// protectedConflict returns a protected managed project using alias or path
+func protectedConflict(managedConfig *config.ManagedConfig, alias, path string) (model.ManagedProject, bool) {
+	for _, p := range managedConfig.Projects {
+		if p.Protected && (p.Alias == alias || p.Project.Path == path) {
+			return p, true
+		}
+	}
+	return model.ManagedProject{}, false
+}
+
+// removeManagedProjects removes the managed projects using alias or path
+func removeManagedProjects(managedConfig *config.ManagedConfig, alias, path string) {
+	kept := managedConfig.Projects[:0]
+	for _, p := range managedConfig.Projects {
+		if p.Alias != alias && p.Project.Path != path {
+			kept = append(kept, p)
+		}
+	}
+	managedConfig.Projects = kept
+}
@@ -0,0 +1,108 @@
+package manager
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitas/dcm/internal/config"
+	"github.com/mitas/dcm/internal/model"
+)
+
+func TestImportOverwriteProtectedProjects(t *testing.T) {
+	root := t.TempDir()
+	api := composeProject(t, filepath.Join(root, "api"))
+	manifest := &model.Manifest{Projects: []model.ManifestProject{{Alias: "api", Path: "api", File: "compose.yaml"}}}
+
+	// The managed project has settings the manifest does not carry
+	protected := api
+	protected.Profiles = []string{"dev"}
+	newConfig := func() *config.ManagedConfig {
+		return &config.ManagedConfig{Projects: []model.ManagedProject{{Alias: "api", Project: protected, Protected: true}}}
+	}
+
+	m := NewManager(nil)
+
+	managedConfig := newConfig()
+	results, err := m.ImportManifest(managedConfig, manifest, root, ConflictOverwrite, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Success || !strings.Contains(results[0].Error.Error(), "protected") {
+		t.Errorf("got %+v, want the protected project to be refused", results[0])
+	}
+	if len(managedConfig.Projects[0].Project.Profiles) != 1 {
+		t.Errorf("protected project was overwritten: %+v", managedConfig.Projects[0])
+	}
+
+	managedConfig = newConfig()
+	results, err = m.ImportManifest(managedConfig, manifest, root, ConflictOverwrite, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Success {
+		t.Errorf("got %+v, want the project overwritten with --force", results[0])
+	}
+	// Settings the manifest does not carry are kept
+	if got := managedConfig.Projects[0]; len(got.Project.Profiles) != 1 || !got.Protected {
+		t.Errorf("overwritten project is %+v, want its profiles and protection kept", got)
+	}
+}
+
+func TestImportOverwriteKeepsSettings(t *testing.T) {
+	root := t.TempDir()
+	api := composeProject(t, filepath.Join(root, "services", "api"))
+	manifest := &model.Manifest{Projects: []model.ManifestProject{{Alias: "api", Name: "api", Path: "services/api", File: "compose.yaml"}}}
+
+	// The alias points elsewhere and carries local settings
+	old := model.Project{Name: "api", Path: "/old/api", File: "docker-compose.yml"}
+	old.Profiles = []string{"dev"}
+	old.DependsOn = []string{"db"}
+	old.DockerContext = "remote"
+	managedConfig := &config.ManagedConfig{Projects: []model.ManagedProject{{Alias: "api", Project: old}}}
+
+	results, err := NewManager(nil).ImportManifest(managedConfig, manifest, root, ConflictOverwrite, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Success {
+		t.Fatal(results[0].Error)
+	}
+	if len(managedConfig.Projects) != 1 {
+		t.Fatalf("got %+v, want a single project", managedConfig.Projects)
+	}
+	got := managedConfig.Projects[0].Project
+	if got.Path != api.Path || got.File != "compose.yaml" {
+		t.Errorf("project is at %s/%s, want the manifest location", got.Path, got.File)
+	}
+	if len(got.Profiles) != 1 || len(got.DependsOn) != 1 || got.DockerContext != "remote" {
+		t.Errorf("project settings were lost: %+v", got.ProjectSettings)
+	}
+}
+
+func TestImportPathInsideRoot(t *testing.T) {
+	root := t.TempDir()
+	composeProject(t, filepath.Join(root, "..cache", "api"))
+
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{"..cache/api", true},
+		{"..", false},
+		{"../api", false},
+		{"api/../../api", false},
+	}
+
+	for _, tt := range tests {
+		managedConfig := &config.ManagedConfig{}
+		manifest := &model.Manifest{Projects: []model.ManifestProject{{Alias: "api", Path: tt.path, File: "compose.yaml"}}}
+		results, err := NewManager(nil).ImportManifest(managedConfig, manifest, root, ConflictSkip, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if results[0].Success != tt.ok {
+			t.Errorf("%s: got %+v, want success %v", tt.path, results[0], tt.ok)
+		}
+	}
+}
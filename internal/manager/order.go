@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"github.com/mitas/dcm/internal/model"
+)
+
+// dependencyOrder sorts projects so each one comes after the projects of the
+// set it depends on, keeping the given order where depends_on allows it.
+// With reverse set, dependents come first, the order to stop them in.
+// before[i] holds the indexes in ordered of the projects to finish before
+// ordered[i]. Projects in or behind a dependency cycle are returned in cyclic.
+func (m *Manager) dependencyOrder(projects []model.Project, reverse bool) (ordered []model.Project, before [][]int, cyclic []model.Project) {
+	// waitsFor[i] lists the projects handled before projects[i]
+	waitsFor := make([][]int, len(projects))
+	for i, p := range projects {
+		// Dependencies that cannot be resolved are reported when starting
+		resolved, err := m.ResolveProject(p)
+		if err != nil {
+			continue
+		}
+		for _, name := range resolved.DependsOn {
+			for j, dependency := range projects {
+				if j == i || dependency.Name != name {
+					continue
+				}
+				if reverse {
+					waitsFor[j] = append(waitsFor[j], i)
+				} else {
+					waitsFor[i] = append(waitsFor[i], j)
+				}
+			}
+		}
+	}
+
+	position := make([]int, len(projects))
+	for i := range position {
+		position[i] = -1
+	}
+
+	// Take the first project whose projects to wait for are all placed
+	for len(ordered) < len(projects) {
+		next := -1
+		for i := range projects {
+			if position[i] != -1 {
+				continue
+			}
+			ready := true
+			for _, j := range waitsFor[i] {
+				if position[j] == -1 {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			break
+		}
+
+		position[next] = len(ordered)
+		ordered = append(ordered, projects[next])
+		waits := make([]int, 0, len(waitsFor[next]))
+		for _, j := range waitsFor[next] {
+			waits = append(waits, position[j])
+		}
+		before = append(before, waits)
+	}
+
+	for i, p := range projects {
+		if position[i] == -1 {
+			cyclic = append(cyclic, p)
+		}
+	}
+	return ordered, before, cyclic
+}
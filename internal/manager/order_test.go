@@ -0,0 +1,111 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// dependentProjects creates web depending on api depending on db, listed
+// with the dependents first
+func dependentProjects(t *testing.T) []model.Project {
+	root := t.TempDir()
+	db := composeProject(t, filepath.Join(root, "db"))
+	api := composeProject(t, filepath.Join(root, "api"))
+	api.DependsOn = []string{"db"}
+	web := composeProject(t, filepath.Join(root, "web"))
+	web.DependsOn = []string{"api"}
+	return []model.Project{web, api, db}
+}
+
+// projectOrder returns the project directories in the order the lines of
+// output mention them
+func projectOrder(output string) []string {
+	var order []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		dir, _, _ := strings.Cut(strings.TrimPrefix(line, "cd "), " ")
+		order = append(order, filepath.Base(dir))
+	}
+	return order
+}
+
+func TestDryRunFollowsDependencies(t *testing.T) {
+	fakeBinaries(t, map[string]string{"docker": "exit 0\n"})
+
+	tests := []struct {
+		action model.ActionType
+		want   string
+	}{
+		{model.ActionStart, "db api web"},
+		{model.ActionStop, "web api db"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		m := NewManager(nil)
+		m.SetBackend(dockerBackend{})
+		m.SetParallelism(1)
+		m.SetDryRun(&out)
+
+		results := m.ManageAllProjects(context.Background(), dependentProjects(t), tt.action)
+		for _, result := range results {
+			if !result.Success {
+				t.Errorf("%s: %v", result.Project.Name, result.Error)
+			}
+		}
+		if got := strings.Join(projectOrder(out.String()), " "); got != tt.want {
+			t.Errorf("action %d ran in order %s, want %s:\n%s", tt.action, got, tt.want, out.String())
+		}
+	}
+}
+
+func TestStartSkipsDependentsOfFailedProjects(t *testing.T) {
+	// Compose fails in the db directory only
+	fakeBinaries(t, map[string]string{"docker": `case "$PWD" in */db) exit 1 ;; esac
+exit 0
+`})
+
+	m := NewManager(nil)
+	m.SetBackend(dockerBackend{})
+	m.SetRetry(0, 0)
+
+	results := m.ManageAllProjects(context.Background(), dependentProjects(t), model.ActionStart)
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	for _, result := range results {
+		if result.Success {
+			t.Errorf("%s started, want it failed or skipped", result.Project.Name)
+		}
+		if result.Project.Name == "api" && !strings.Contains(result.Error.Error(), "dependency db failed to start") {
+			t.Errorf("api: got %v, want the failed dependency", result.Error)
+		}
+	}
+}
+
+func TestDependencyCycle(t *testing.T) {
+	fakeBinaries(t, map[string]string{"docker": "exit 0\n"})
+
+	projects := dependentProjects(t)
+	// db depends on web, closing the cycle
+	projects[2].DependsOn = []string{"web"}
+	extra := composeProject(t, filepath.Join(t.TempDir(), "cache"))
+
+	m := NewManager(nil)
+	m.SetBackend(dockerBackend{})
+
+	results := m.ManageAllProjects(context.Background(), append(projects, extra), model.ActionStart)
+	for _, result := range results {
+		if result.Project.Name == "cache" {
+			if !result.Success {
+				t.Errorf("cache: %v", result.Error)
+			}
+		} else if result.Error == nil || !strings.Contains(result.Error.Error(), "dependency cycle") {
+			t.Errorf("%s: got %v, want a dependency cycle", result.Project.Name, result.Error)
+		}
+	}
+}
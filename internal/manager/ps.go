@@ -0,0 +1,164 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// composeConfigFilesLabel lists the compose files a container was created from
+const composeConfigFilesLabel = "com.docker.compose.project.config_files"
+
+// ListEngineProjects lists every compose project known to the engine,
+// from the Docker Engine API when an engine client is set, else with the
+// default backend. Projects are sorted by name.
+func (m *Manager) ListEngineProjects() ([]model.EngineProject, error) {
+	var projects []model.EngineProject
+
+	if m.engine != nil {
+		containers, err := m.engine.ComposeContainers()
+		if err != nil {
+			return nil, err
+		}
+		projects = groupEngineProjects(containers)
+	} else {
+		backend, err := m.defaultBackend()
+		if err != nil {
+			return nil, err
+		}
+		lister, ok := backend.(ProjectLister)
+		if !ok {
+			return nil, fmt.Errorf("the %s backend cannot list compose projects, use --status-backend %s", backend.Name(), StatusEngine)
+		}
+
+		command := lister.ListProjectsCommand()
+		output, err := m.executor.Execute("", command.Program, command.Args...)
+		if err != nil {
+			return nil, fmt.Errorf("error listing compose projects: %w: %s", err, strings.TrimSpace(string(output)))
+		}
+		if projects, err = lister.ParseProjects(output); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return projects, nil
+}
+
+// groupEngineProjects groups containers by compose project, summarising
+// their states like `docker compose ls` does
+func groupEngineProjects(containers []EngineContainer) []model.EngineProject {
+	var projects []model.EngineProject
+	states := make(map[string]map[string]int)
+	index := make(map[string]int)
+
+	for _, c := range containers {
+		name := c.Labels[composeProjectLabel]
+		i, ok := index[name]
+		if !ok {
+			project := model.EngineProject{Name: name, WorkingDir: c.Labels[composeWorkingDirLabel]}
+			if files := c.Labels[composeConfigFilesLabel]; files != "" {
+				project.ConfigFiles = strings.Split(files, ",")
+			}
+			i = len(projects)
+			index[name] = i
+			states[name] = make(map[string]int)
+			projects = append(projects, project)
+		}
+		states[name][c.State]++
+	}
+
+	for i := range projects {
+		counts := states[projects[i].Name]
+		names := make([]string, 0, len(counts))
+		for state := range counts {
+			names = append(names, state)
+		}
+		sort.Strings(names)
+
+		parts := make([]string, 0, len(names))
+		for _, state := range names {
+			parts = append(parts, fmt.Sprintf("%s(%d)", state, counts[state]))
+		}
+		projects[i].Status = strings.Join(parts, ", ")
+	}
+	return projects
+}
+
+// CorrelateEngineProjects matches the engine projects to the managed and
+// discovered projects by working directory. Managed projects come first so
+// the alias is known; engine projects left without a match are orphans.
+func (m *Manager) CorrelateEngineProjects(engineProjects []model.EngineProject, managed []model.ManagedProject, projects []model.Project) []model.EngineProject {
+	type candidate struct {
+		alias   string
+		project model.Project
+	}
+	byDir := make(map[string]candidate)
+	for i := len(projects) - 1; i >= 0; i-- {
+		byDir[canonicalPath(projects[i].Path)] = candidate{project: projects[i]}
+	}
+	for i := len(managed) - 1; i >= 0; i-- {
+		byDir[canonicalPath(managed[i].Project.Path)] = candidate{alias: managed[i].Alias, project: managed[i].Project}
+	}
+
+	correlated := make([]model.EngineProject, 0, len(engineProjects))
+	for _, p := range engineProjects {
+		if p.WorkingDir != "" {
+			if match, ok := byDir[canonicalPath(p.WorkingDir)]; ok {
+				project := match.project
+				p.Alias = match.alias
+				p.Project = &project
+			}
+		}
+		correlated = append(correlated, p)
+	}
+	return correlated
+}
+
+// canonicalPath returns an absolute path with symlinks resolved when possible
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if absolute, err := filepath.Abs(path); err == nil {
+		path = absolute
+	}
+	return filepath.Clean(path)
+}
+
+// StopEngineProject runs compose down on an engine project by name, which
+// works when its compose files are gone
+func (m *Manager) StopEngineProject(project model.EngineProject) model.Result {
+	result := model.Result{Project: model.Project{Name: project.Name, Path: project.WorkingDir}}
+
+	backend, err := m.defaultBackend()
+	if err != nil {
+		result.Error = fmt.Errorf("error stopping %s: %w", project.Name, err)
+		return result
+	}
+	command, err := backend.Command(model.Project{}, "-p", project.Name, "down")
+	if err != nil {
+		result.Error = fmt.Errorf("error stopping %s: %w", project.Name, err)
+		return result
+	}
+
+	// Run from the working directory if it still exists, for relative paths
+	dir := ""
+	if info, err := os.Stat(project.WorkingDir); err == nil && info.IsDir() {
+		dir = project.WorkingDir
+	}
+
+	output, err := m.executor.ExecuteEnv(dir, command.Env, command.Program, command.Args...)
+	if err != nil {
+		result.Error = fmt.Errorf("error stopping %s: %w: %s", project.Name, err, output)
+		return result
+	}
+
+	result.Success = true
+	result.Message = m.stoppedMessage("orphan compose project "+project.Name, 1)
+	return result
+}
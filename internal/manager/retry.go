@@ -0,0 +1,93 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// maxRetryDelay caps the backoff between two attempts
+const maxRetryDelay = time.Minute
+
+// retryablePattern matches compose output of failures that may succeed when
+// retried: ports still held by exiting containers, registry and network
+// hiccups, and a busy or restarting daemon. Anything else, such as an
+// invalid compose file or a missing image, is permanent.
+var retryablePattern = regexp.MustCompile(`(?i)` + strings.Join([]string{
+	`address already in use`,
+	`port is already allocated`,
+	`tls handshake timeout`,
+	`i/o timeout`,
+	`connection reset by peer`,
+	`connection refused`,
+	`context deadline exceeded`,
+	`temporary failure in name resolution`,
+	`toomanyrequests|too many requests`,
+	`service unavailable|bad gateway|gateway timeout`,
+	`received unexpected http status: 5\d\d`,
+	`cannot connect to the docker daemon`,
+	`is already in progress`,
+	`device or resource busy`,
+}, "|"))
+
+// IsRetryable reports whether the output of a failed compose command
+// describes a transient failure
+func IsRetryable(output string) bool {
+	return retryablePattern.MatchString(output)
+}
+
+// SetRetry makes compose up and down retry transient failures up to retries
+// times, waiting backoff before the first retry and doubling it after each
+func (m *Manager) SetRetry(retries int, backoff time.Duration) {
+	m.retries = retries
+	m.retryBackoff = backoff
+}
+
+// retryDelay returns the wait before a retry, 1 for the first: the backoff
+// doubled for each previous retry, with its upper half randomised so that
+// projects failing together do not retry together
+func retryDelay(backoff time.Duration, retry int) time.Duration {
+	delay := backoff
+	for i := 1; i < retry && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxRetryDelay)
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// composeWithRetry runs a compose subcommand for a resolved project,
+// retrying transient failures until ctx is done, and returns the number
+// of attempts
+func (m *Manager) composeWithRetry(ctx context.Context, project model.Project, args ...string) ([]byte, int, error) {
+	for attempt := 1; ; attempt++ {
+		output, err := m.compose(project, args...)
+		if err == nil || attempt > m.retries || !IsRetryable(string(output)) {
+			return output, attempt, err
+		}
+
+		timer := time.NewTimer(retryDelay(m.retryBackoff, attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return output, attempt, fmt.Errorf("%w, not retried: %v", err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// attemptsSuffix describes the attempts of a command run more than once
+func attemptsSuffix(attempts int) string {
+	if attempts <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(" after %d attempts", attempts)
+}
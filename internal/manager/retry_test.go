@@ -0,0 +1,62 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+func TestRetryStopsWhenContextIsDone(t *testing.T) {
+	fakeBinaries(t, map[string]string{"docker": "echo 'Bind for 0.0.0.0:80 failed: port is already allocated'\nexit 1\n"})
+
+	m := NewManager(nil)
+	m.SetBackend(dockerBackend{})
+	m.SetRetry(5, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	begin := time.Now()
+	_, attempts, err := m.composeWithRetry(ctx, model.Project{Name: "api", Path: t.TempDir()}, "up", "-d")
+	if elapsed := time.Since(begin); elapsed > 5*time.Second {
+		t.Errorf("gave up after %s, want right after the deadline", elapsed)
+	}
+	if attempts != 1 {
+		t.Errorf("made %d attempts, want 1", attempts)
+	}
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Errorf("got %v, want the deadline in the error", err)
+	}
+	var exitErr interface{ ExitCode() int }
+	if !errors.As(err, &exitErr) {
+		t.Errorf("got %v, want the compose error to be kept", err)
+	}
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("COUNTER", dir+"/count")
+	// Fails twice with a transient error, then succeeds
+	fakeBinaries(t, map[string]string{"docker": `read count < "$COUNTER" || count=0
+count=$((count + 1))
+echo $count > "$COUNTER"
+if [ $count -le 2 ]; then echo "TLS handshake timeout"; exit 1; fi
+echo started
+`})
+
+	m := NewManager(nil)
+	m.SetBackend(dockerBackend{})
+	m.SetRetry(3, time.Millisecond)
+
+	output, attempts, err := m.composeWithRetry(context.Background(), model.Project{Name: "api", Path: dir}, "up", "-d")
+	if err != nil {
+		t.Fatalf("%v: %s", err, output)
+	}
+	if attempts != 3 {
+		t.Errorf("made %d attempts, want 3", attempts)
+	}
+}
@@ -0,0 +1,144 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitas/dcm/internal/config"
+	"github.com/mitas/dcm/internal/model"
+)
+
+// InspectProject merges the project's .dcm.yaml under its managed settings
+// and reports where each effective setting comes from
+func (m *Manager) InspectProject(alias string, project model.Project) (model.ProjectInspection, error) {
+	inspection := model.ProjectInspection{
+		Alias:   alias,
+		Project: project,
+		Sources: map[string]string{},
+	}
+
+	local, err := m.store.LoadLocalConfig(project.Path)
+	if err != nil {
+		return inspection, err
+	}
+	if local == nil {
+		local = &config.LocalConfig{}
+	} else {
+		inspection.LocalConfig = filepath.Join(project.Path, config.LocalConfigFileName)
+	}
+
+	inspection.Project.ProjectSettings, inspection.Sources = config.MergeProjectSettings(project.ProjectSettings, local.ProjectSettings)
+	return inspection, nil
+}
+
+// ResolveProject returns the project with its effective settings
+func (m *Manager) ResolveProject(project model.Project) (model.Project, error) {
+	inspection, err := m.InspectProject("", project)
+	if err != nil {
+		return project, err
+	}
+	if inspection.Project.DockerContext != "" && inspection.Project.DockerHost != "" {
+		return project, fmt.Errorf("docker_context and docker_host cannot both be set")
+	}
+	return inspection.Project, nil
+}
+
+// runHooks runs the shell commands of a hook stage in the project directory,
+// stopping at the first failure. Hooks get the project's environment, so
+// docker commands they run target the same engine as compose.
+func (m *Manager) runHooks(project model.Project, stage string, hooks []string) error {
+	env := projectEnv(project, "DOCKER_HOST")
+	if project.DockerContext != "" {
+		env = append(env, "DOCKER_CONTEXT="+project.DockerContext)
+	}
+	for _, hook := range hooks {
+		output, err := m.executor.ExecuteEnv(project.Path, env, "sh", "-c", hook)
+		if err != nil {
+			return fmt.Errorf("%s hook '%s' failed: %w: %s", stage, hook, err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// StartProjectWithDependencies starts the projects named in DependsOn,
+// recursively, before the project itself. lookup resolves a dependency
+// name to a project. It stops at the first project that fails to start.
+func (m *Manager) StartProjectWithDependencies(ctx context.Context, project model.Project, lookup func(name string) (model.Project, bool)) []model.Result {
+	var results []model.Result
+	started := make(map[string]bool)
+
+	var start func(p model.Project, chain []string) bool
+	start = func(p model.Project, chain []string) bool {
+		if started[p.Path] {
+			return true
+		}
+
+		resolved, err := m.ResolveProject(p)
+		if err != nil {
+			results = append(results, model.Result{Project: p, Success: false, Error: fmt.Errorf("error starting %s: %w", p.Name, err)})
+			return false
+		}
+
+		for _, name := range chain {
+			if name == resolved.Name {
+				cycle := strings.Join(append(chain, resolved.Name), " -> ")
+				results = append(results, model.Result{Project: resolved, Success: false, Error: fmt.Errorf("dependency cycle: %s", cycle)})
+				return false
+			}
+		}
+		chain = append(chain, resolved.Name)
+
+		for _, dependency := range resolved.DependsOn {
+			dependencyProject, found := lookup(dependency)
+			if !found {
+				results = append(results, model.Result{
+					Project: resolved,
+					Success: false,
+					Error:   fmt.Errorf("dependency '%s' of %s not found", dependency, resolved.Name),
+				})
+				return false
+			}
+			if !start(dependencyProject, chain) {
+				return false
+			}
+		}
+
+		result := m.StartProject(ctx, resolved)
+		started[p.Path] = true
+		results = append(results, result)
+		return result.Success
+	}
+
+	start(project, nil)
+	return results
+}
+
+// ManagedProjectLookup resolves dependency names against the managed
+// projects, by exact alias first and then by project name
+func (m *Manager) ManagedProjectLookup(managedConfig *config.ManagedConfig) func(name string) (model.Project, bool) {
+	return func(name string) (model.Project, bool) {
+		if index := managedProjectIndex(managedConfig, name); index != -1 {
+			return managedConfig.Projects[index].Project, true
+		}
+		for _, p := range managedConfig.Projects {
+			if p.Project.Name == name {
+				return p.Project, true
+			}
+		}
+		return model.Project{}, false
+	}
+}
+
+// ProjectLookup resolves dependency names against discovered projects by exact name
+func (m *Manager) ProjectLookup(projects []model.Project) func(name string) (model.Project, bool) {
+	return func(name string) (model.Project, bool) {
+		for _, p := range projects {
+			if p.Name == name {
+				return p, true
+			}
+		}
+		return model.Project{}, false
+	}
+}
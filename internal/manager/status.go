@@ -0,0 +1,259 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// runningPattern matches service statuses that indicate a running container
+var runningPattern = regexp.MustCompile(`(?i)up|running`)
+
+// exitPattern matches the exit code in statuses such as "Exited (1) 2
+// minutes ago" or the "Exit 1" of docker-compose v1
+var exitPattern = regexp.MustCompile(`Exit(?:ed)? \(?(-?\d+)`)
+
+// healthRank orders health states from best to worst, so a service
+// reports the worst state of its containers
+var healthRank = map[string]int{"": 0, "healthy": 1, "starting": 2, "unhealthy": 3}
+
+// composeContainer is a single entry of `docker compose ps --format json`
+type composeContainer struct {
+	Name       string             `json:"Name"`
+	Service    string             `json:"Service"`
+	State      string             `json:"State"`
+	Status     string             `json:"Status"`
+	Health     string             `json:"Health"`
+	Publishers []composePublisher `json:"Publishers"`
+}
+
+// composePublisher describes a port published by a container
+type composePublisher struct {
+	URL           string `json:"URL"`
+	TargetPort    int    `json:"TargetPort"`
+	PublishedPort int    `json:"PublishedPort"`
+	Protocol      string `json:"Protocol"`
+}
+
+// parseComposePS parses the output of `docker compose ps --format json`.
+// Older compose releases print a JSON array, newer ones one object per line.
+func parseComposePS(output []byte) ([]Container, error) {
+	trimmed := bytes.TrimSpace(output)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	var entries []composeContainer
+	if trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("error parsing container list: %w", err)
+		}
+	} else {
+		for _, line := range bytes.Split(trimmed, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+			var entry composeContainer
+			if err := json.Unmarshal(line, &entry); err != nil {
+				return nil, fmt.Errorf("error parsing container list: %w", err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	containers := make([]Container, 0, len(entries))
+	for _, c := range entries {
+		containers = append(containers, Container{
+			Name:    c.Name,
+			Service: c.Service,
+			State:   c.State,
+			Status:  c.Status,
+			Health:  c.Health,
+			Ports:   formatPorts(c.Publishers),
+		})
+	}
+	return containers, nil
+}
+
+// formatPorts renders published ports like `docker ps` does, without duplicates
+func formatPorts(publishers []composePublisher) []string {
+	var ports []string
+	seen := make(map[string]bool)
+	for _, p := range publishers {
+		port := fmt.Sprintf("%d/%s", p.TargetPort, p.Protocol)
+		if p.PublishedPort != 0 {
+			port = fmt.Sprintf("%d->%s", p.PublishedPort, port)
+			if p.URL != "" && p.URL != "0.0.0.0" && p.URL != "::" {
+				port = p.URL + ":" + port
+			}
+		}
+		if !seen[port] {
+			seen[port] = true
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// GetProjectStatus checks the status of a project and returns it as a ProjectStatus.
+// Services are sorted by name so the output is stable.
+func (m *Manager) GetProjectStatus(project model.Project) model.ProjectStatus {
+	return m.GetProjectStatuses([]model.Project{project})[0]
+}
+
+// GetProjectStatuses checks the status of several projects. With an engine
+// client, the containers of every project on the local engine are listed
+// with a single request; other projects are checked with their backend.
+func (m *Manager) GetProjectStatuses(projects []model.Project) []model.ProjectStatus {
+	statuses := make([]model.ProjectStatus, 0, len(projects))
+
+	var engineContainers []EngineContainer
+	var engineErr error
+	listed := false
+
+	for _, project := range projects {
+		project, err := m.ResolveProject(project)
+		if err != nil {
+			statuses = append(statuses, model.ProjectStatus{Project: project, Error: err})
+			continue
+		}
+
+		// The engine client only reaches the engine selected by the environment
+		if m.engine == nil || project.DockerTarget() != "" {
+			statuses = append(statuses, m.composeStatus(project))
+			continue
+		}
+
+		if !listed {
+			engineContainers, engineErr = m.engine.ComposeContainers()
+			listed = true
+		}
+		if engineErr != nil {
+			statuses = append(statuses, model.ProjectStatus{Project: project, Error: fmt.Errorf("error checking status: %w", engineErr)})
+			continue
+		}
+		statuses = append(statuses, engineStatus(project, engineContainers))
+	}
+
+	return statuses
+}
+
+// composeStatus checks the status of a resolved project with its compose backend
+func (m *Manager) composeStatus(project model.Project) model.ProjectStatus {
+	status := model.ProjectStatus{Project: project}
+
+	backend, err := m.projectBackend(project)
+	if err != nil {
+		status.Error = err
+		return status
+	}
+
+	// Check if any containers exist
+	output, err := m.compose(project, backend.ContainersArgs()...)
+	if err != nil {
+		status.Error = fmt.Errorf("error checking status: %w", err)
+		return status
+	}
+
+	containers, err := backend.ParseContainers(output)
+	if err != nil {
+		status.Error = err
+		return status
+	}
+
+	// If there are no containers, project is not running
+	if len(containers) == 0 {
+		return status
+	}
+
+	// Get services from docker-compose.yml
+	servicesOutput, err := m.compose(project, "config", "--services")
+	if err != nil {
+		status.Error = fmt.Errorf("error getting services: %w", err)
+		return status
+	}
+
+	return buildStatus(project, strings.Fields(string(servicesOutput)), containers)
+}
+
+// buildStatus builds the status of a project from its services and
+// containers. Services without a container are reported as not running.
+func buildStatus(project model.Project, services []string, containers []Container) model.ProjectStatus {
+	status := model.ProjectStatus{Project: project}
+	sort.Strings(services)
+
+	for _, service := range services {
+		serviceStatus := model.ServiceStatus{Name: service}
+
+		var states, statuses, ports []string
+		seen := make(map[string]bool)
+		exited := 0
+		for _, c := range containers {
+			if c.Service != service {
+				continue
+			}
+			states = append(states, c.State)
+			statuses = append(statuses, c.Status)
+			if healthRank[c.Health] > healthRank[serviceStatus.Health] {
+				serviceStatus.Health = c.Health
+			}
+			if match := exitPattern.FindStringSubmatch(c.Status); c.State == "exited" || match != nil {
+				exited++
+				if match != nil {
+					code, _ := strconv.Atoi(match[1])
+					serviceStatus.ExitCode = max(serviceStatus.ExitCode, code)
+				}
+			}
+			for _, port := range c.Ports {
+				if !seen[port] {
+					seen[port] = true
+					ports = append(ports, port)
+				}
+			}
+			if c.State == "running" || runningPattern.MatchString(c.Status) {
+				serviceStatus.Running = true
+			}
+		}
+
+		if len(statuses) == 0 {
+			serviceStatus.Status = "not running"
+		} else {
+			serviceStatus.State = strings.Join(states, ", ")
+			serviceStatus.Status = strings.Join(statuses, ", ")
+			serviceStatus.Ports = ports
+			serviceStatus.Exited = exited == len(statuses)
+		}
+
+		if serviceStatus.Running {
+			status.Running = true
+		}
+		status.Services = append(status.Services, serviceStatus)
+	}
+
+	return status
+}
+
+// CheckProjectStatus checks the status of a docker-compose project and
+// returns whether it is running along with the status of each service
+func (m *Manager) CheckProjectStatus(project model.Project) (bool, map[string]string, error) {
+	status := m.GetProjectStatus(project)
+	if status.Error != nil {
+		return false, nil, status.Error
+	}
+	if len(status.Services) == 0 {
+		return false, nil, nil
+	}
+
+	services := make(map[string]string, len(status.Services))
+	for _, service := range status.Services {
+		services[service.Name] = service.Status
+	}
+	return status.Running, services, nil
+}
@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// waitInterval is the delay between two status checks while waiting
+const waitInterval = time.Second
+
+// waitLogLines is the number of log lines shown for a failing service
+const waitLogLines = 20
+
+// SetWait makes starting a project wait up to timeout for its services to
+// become ready, 0 to return as soon as compose up returns
+func (m *Manager) SetWait(timeout time.Duration) {
+	m.waitTimeout = timeout
+}
+
+// serviceReadiness tells whether a service is ready, still starting or failed
+func serviceReadiness(service model.ServiceStatus) (ready bool, failure string) {
+	switch {
+	case service.Status == "not running" && service.State == "":
+		// Nothing was created for the service, e.g. it is scaled to zero
+		return true, ""
+	case service.Health == "unhealthy":
+		return false, "is unhealthy"
+	case service.Exited && service.ExitCode != 0:
+		return false, fmt.Sprintf("exited with code %d", service.ExitCode)
+	case service.Exited:
+		// One-shot services are done once they exit successfully
+		return true, ""
+	case service.Running:
+		return service.Health == "" || service.Health == "healthy", ""
+	}
+	return false, ""
+}
+
+// waitForProject polls the status of a resolved project until every service
+// is running and healthy or has completed successfully. It fails as soon
+// as a service is unhealthy or exits with an error, when timeout expires,
+// reporting the service and its last log lines, or when ctx is done.
+func (m *Manager) waitForProject(ctx context.Context, project model.Project, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(waitInterval)
+	defer ticker.Stop()
+
+	for {
+		status := m.GetProjectStatus(project)
+		if status.Error != nil {
+			return status.Error
+		}
+
+		var pending []model.ServiceStatus
+		created := false
+		for _, service := range status.Services {
+			ready, failure := serviceReadiness(service)
+			if failure != "" {
+				return m.serviceError(project, service.Name, failure)
+			}
+			if !ready {
+				pending = append(pending, service)
+			}
+			if service.State != "" {
+				created = true
+			}
+		}
+		// Right after up, compose may not list the containers yet
+		if created && len(pending) == 0 {
+			return nil
+		}
+
+		waitingFor := "containers to be created"
+		if len(pending) > 0 {
+			names := make([]string, 0, len(pending))
+			for _, service := range pending {
+				names = append(names, fmt.Sprintf("%s (%s)", service.Name, service.Status))
+			}
+			waitingFor = strings.Join(names, ", ")
+		}
+
+		if time.Now().After(deadline) {
+			if len(pending) == 0 {
+				return fmt.Errorf("no containers of %s were created after %s", project.Name, timeout)
+			}
+			return m.serviceError(project, pending[0].Name,
+				fmt.Sprintf("is not ready after %s, waiting for: %s", timeout, waitingFor))
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped waiting for %s: %w", waitingFor, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// serviceError describes a service that did not become ready, with its last log lines
+func (m *Manager) serviceError(project model.Project, service, failure string) error {
+	output, err := m.compose(project, "logs", "--no-color", "--tail", fmt.Sprint(waitLogLines), service)
+	logs := strings.TrimRight(string(output), "\n")
+	if err != nil || logs == "" {
+		return fmt.Errorf("service %s %s", service, failure)
+	}
+	return fmt.Errorf("service %s %s, last log lines:\n%s", service, failure, logs)
+}
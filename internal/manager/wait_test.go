@@ -0,0 +1,75 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// waitingManager runs fakeCompose, accepting up, with ps printing output,
+// waiting for services up to timeout
+func waitingManager(t *testing.T, output string, timeout time.Duration) (*Manager, model.Project) {
+	t.Helper()
+	fakeBinaries(t, map[string]string{"docker": "case \" $* \" in *\" up \"*) exit 0 ;; esac\n" + fakeCompose})
+	t.Setenv("FAKE_PS", output)
+
+	m := NewManager(nil)
+	m.SetBackend(dockerBackend{})
+	m.SetWait(timeout)
+	return m, composeProject(t, t.TempDir())
+}
+
+func TestWaitReady(t *testing.T) {
+	// web is healthy, db has no container as it is scaled to zero
+	web := strings.Split(dockerPS, "\n")[0]
+	m, project := waitingManager(t, web, time.Minute)
+
+	if err := m.waitForProject(context.Background(), project, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitFailedService(t *testing.T) {
+	m, project := waitingManager(t, dockerPS, time.Minute)
+
+	err := m.waitForProject(context.Background(), project, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "service db exited with code 1") {
+		t.Errorf("got %v, want db to fail", err)
+	}
+}
+
+func TestWaitWithoutContainers(t *testing.T) {
+	// ps lists nothing yet right after up
+	m, project := waitingManager(t, "", time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	begin := time.Now()
+	err := m.waitForProject(ctx, project, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Errorf("got %v, want to wait until the context is done", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 5*time.Second {
+		t.Errorf("stopped waiting after %s, want right after the context deadline", elapsed)
+	}
+
+	if err := m.waitForProject(context.Background(), project, time.Millisecond); err == nil || !strings.Contains(err.Error(), "no containers") {
+		t.Errorf("got %v, want no containers after the wait timeout", err)
+	}
+}
+
+func TestStartWaitStopsWithContext(t *testing.T) {
+	m, project := waitingManager(t, "", time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	result := m.StartProject(ctx, project)
+	if result.Success || !strings.Contains(result.Error.Error(), "stopped waiting") {
+		t.Errorf("got %+v, want the start to stop waiting with the context", result)
+	}
+}
@@ -0,0 +1,35 @@
+package model
+
+// MigrationResult describes the upgrade of a config file to the current schema
+type MigrationResult struct {
+	Path        string
+	FromVersion int
+	ToVersion   int
+	// Diff shows the changes to the file, empty when it is already current
+	Diff string
+	// Backup is where the previous file was saved, empty for dry runs
+	Backup string
+	DryRun bool
+}
+
+// ConfigIssue describes a problem with a managed project in the config file
+type ConfigIssue struct {
+	// Alias is the managed project the issue is about
+	Alias   string
+	Message string
+}
+
+// ValidationResult describes the outcome of validating the config file
+type ValidationResult struct {
+	Path   string
+	Issues []ConfigIssue
+}
+
+// ContextInfo describes a dcm context
+type ContextInfo struct {
+	Name    string
+	Current bool
+	// File is the config file storing the context, empty when it is inline
+	File     string
+	Projects int
+}
@@ -0,0 +1,23 @@
+package model
+
+// Manifest is a portable list of managed projects shared by a team.
+// Paths are relative to the root of a workspace, so each machine can
+// check the projects out wherever it likes.
+type Manifest struct {
+	Version int `yaml:"version"`
+	// Workspace names the root the paths are relative to, e.g. "src"
+	Workspace string            `yaml:"workspace,omitempty"`
+	Projects  []ManifestProject `yaml:"projects"`
+}
+
+// ManifestProject is a managed project in a manifest
+type ManifestProject struct {
+	Alias string `yaml:"alias"`
+	Name  string `yaml:"name"`
+	// Path is relative to the workspace root, using forward slashes
+	Path     string   `yaml:"path"`
+	File     string   `yaml:"file,omitempty"`
+	EnvFiles []string `yaml:"env_files,omitempty"`
+	// GitRemote is the URL to clone the project from, if known
+	GitRemote string `yaml:"git_remote,omitempty"`
+}
@@ -5,6 +5,71 @@ type Project struct {
 	Name string `yaml:"name"`
 	Path string `yaml:"path"`
 	File string `yaml:"file"`
+	// Workspace names the workspace the project was discovered in, if any.
+	// It is not saved with managed projects.
+	Workspace string `yaml:"-"`
+	// ProjectSettings set in the managed config take precedence over the
+	// project's own .dcm.yaml
+	ProjectSettings `yaml:",inline"`
+}
+
+// ProjectSettings customise how docker compose is run for a project
+type ProjectSettings struct {
+	// Profiles are enabled with --profile
+	Profiles []string `yaml:"profiles,omitempty"`
+	// ComposeFiles are extra compose files passed after File, relative to Path
+	ComposeFiles []string `yaml:"compose_files,omitempty"`
+	// EnvFiles are passed to docker compose with --env-file, relative to Path
+	EnvFiles []string `yaml:"env_files,omitempty"`
+	// DependsOn names the projects to start before this one
+	DependsOn []string `yaml:"depends_on,omitempty"`
+	// Hooks are shell commands run in Path around start and stop
+	Hooks Hooks `yaml:"hooks,omitempty"`
+	// DockerContext is the Docker context compose commands run against,
+	// passed with docker --context
+	DockerContext string `yaml:"docker_context,omitempty"`
+	// DockerHost is the Docker daemon compose commands run against, passed
+	// as DOCKER_HOST. It cannot be combined with DockerContext.
+	DockerHost string `yaml:"docker_host,omitempty"`
+	// Env holds extra environment variables for compose commands and hooks
+	Env map[string]string `yaml:"env,omitempty"`
+	// ComposeBackend selects the compose engine: docker, docker-compose,
+	// podman or nerdctl. It overrides the compose_backend setting.
+	ComposeBackend string `yaml:"compose_backend,omitempty"`
+}
+
+// DockerTarget describes the Docker engine the project runs on, empty for
+// the engine selected by the environment
+func (s ProjectSettings) DockerTarget() string {
+	switch {
+	case s.DockerContext != "":
+		return "context " + s.DockerContext
+	case s.DockerHost != "":
+		return s.DockerHost
+	}
+	return ""
+}
+
+// Hooks are shell commands run around docker compose actions.
+// A failing pre hook aborts the action.
+type Hooks struct {
+	PreStart  []string `yaml:"pre_start,omitempty"`
+	PostStart []string `yaml:"post_start,omitempty"`
+	PreStop   []string `yaml:"pre_stop,omitempty"`
+	PostStop  []string `yaml:"post_stop,omitempty"`
+}
+
+// ProjectInspection describes the effective settings of a project and
+// where each of them comes from
+type ProjectInspection struct {
+	// Alias is empty for projects that are not managed
+	Alias   string
+	Project Project
+	// LocalConfig is the path of the project's .dcm.yaml, empty if it has none
+	LocalConfig string
+	// Sources maps each set setting, e.g. "profiles" or "hooks.pre_start",
+	// to "managed" or "local"
+	Sources map[string]string
 }
 
 // ManagedProject represents a saved docker-compose project
@@ -13,6 +78,8 @@ type ManagedProject struct {
 	Alias string `yaml:"alias"`
 	// Project contains the actual project data
 	Project Project `yaml:"project"`
+	// Protected projects are only stopped or removed with --force
+	Protected bool `yaml:"protected,omitempty"`
 }
 
 // ActionType defines what action to perform on docker-compose projects
@@ -35,4 +102,57 @@ type Result struct {
 	Success bool
 	Message string
 	Error   error
+	// Attempts is how many times the compose command ran, more than one
+	// when transient failures were retried
+	Attempts int
+}
+
+// ServiceStatus represents the state of a single docker-compose service
+type ServiceStatus struct {
+	Name string
+	// State is the container state reported by docker, e.g. running or exited
+	State string
+	// Status is the human-readable status, e.g. "Up 5 minutes"
+	Status  string
+	Running bool
+	// Ports lists the published ports, e.g. 8080->80/tcp
+	Ports []string
+	// Health is the health check state: healthy, unhealthy, starting, or
+	// empty when the service has no health check
+	Health string
+	// Exited reports whether every container of the service has exited,
+	// as one-shot services do when they complete
+	Exited bool
+	// ExitCode is the highest exit code of the exited containers
+	ExitCode int
+}
+
+// ProjectStatus represents the state of a docker-compose project
+type ProjectStatus struct {
+	Project  Project
+	Running  bool
+	Services []ServiceStatus
+	Error    error
+}
+
+// EngineProject is a compose project known to the container engine. It
+// may have been started from a directory dcm does not know or that no
+// longer exists.
+type EngineProject struct {
+	// Name is the compose project name
+	Name string
+	// Status summarises the container states, e.g. "running(2), exited(1)"
+	Status string
+	// WorkingDir is the directory the project was started from
+	WorkingDir  string
+	ConfigFiles []string
+	// Alias is the managed project started from WorkingDir, if any
+	Alias string
+	// Project is the managed or discovered project started from WorkingDir
+	Project *Project
+}
+
+// Orphan reports whether no managed or discovered project matches
+func (p EngineProject) Orphan() bool {
+	return p.Project == nil
 }
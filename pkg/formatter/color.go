@@ -0,0 +1,49 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Color modes accepted by --color
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// UseColor decides whether output written to w should be colored.
+// In auto mode NO_COLOR disables colors, CLICOLOR_FORCE enables them and
+// otherwise colors are only used when w is a terminal.
+func UseColor(mode string, w io.Writer) (bool, error) {
+	switch mode {
+	case ColorAlways:
+		return true, nil
+	case ColorNever:
+		return false, nil
+	case "", ColorAuto:
+	default:
+		return false, fmt.Errorf("unknown color mode '%s' (valid modes: %s, %s, %s)", mode, ColorAuto, ColorAlways, ColorNever)
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false, nil
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true, nil
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false, nil
+	}
+	return IsTerminal(w), nil
+}
+
+// IsTerminal reports whether w is a terminal
+func IsTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return isTerminal(file.Fd())
+}
@@ -0,0 +1,33 @@
+package formatter
+
+import (
+	"os"
+	"testing"
+)
+
+func TestNotATerminal(t *testing.T) {
+	// /dev/null is a character device but no terminal
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	_, pipe, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pipe.Close()
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "")
+	t.Setenv("TERM", "xterm")
+	for _, file := range []*os.File{devNull, pipe} {
+		if IsTerminal(file) {
+			t.Errorf("%s is taken for a terminal", file.Name())
+		}
+		if color, err := UseColor(ColorAuto, file); err != nil || color {
+			t.Errorf("%s is colored", file.Name())
+		}
+	}
+}
@@ -2,7 +2,6 @@ package formatter
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/mitas/dcm/internal/model"
 )
@@ -20,99 +19,87 @@ const (
 	ColorBold   = "\033[1m"
 )
 
-// Formatter handles output formatting with colors and emojis
-type Formatter struct{}
-
-// NewFormatter creates a new formatter
-func NewFormatter() *Formatter {
-	return &Formatter{}
-}
-
-// FormatProjectList formats the list of projects for display
-func (f *Formatter) FormatProjectList(projects []model.Project) string {
-	if len(projects) == 0 {
-		return fmt.Sprintf("%s❌ No Docker Compose projects found%s", ColorRed, ColorReset)
-	}
-
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("%s📋 Found %s%d%s Docker Compose projects:%s\n",
-		ColorBold, ColorGreen, len(projects), ColorReset, ColorReset))
-
-	for i, project := range projects {
-		sb.WriteString(fmt.Sprintf("%s📁 %d.%s %s%s%s (%s/%s)\n",
-			ColorBlue, i+1, ColorReset,
-			ColorBold, project.Name, ColorReset,
-			project.Path, project.File))
-	}
-
-	return sb.String()
-}
-
-// FormatProjectStatus formats the status of a project
-func (f *Formatter) FormatProjectStatus(projectName, projectPath string, running bool, services map[string]string) string {
-	var sb strings.Builder
-
-	sb.WriteString(fmt.Sprintf("\n%s=== Status of %s%s%s (%s) ===%s\n",
-		ColorBold, ColorBlue, projectName, ColorReset, projectPath, ColorReset))
-
-	if len(services) == 0 {
-		sb.WriteString(fmt.Sprintf("%s🛑 Project is not running (no containers)%s\n", ColorYellow, ColorReset))
-		return sb.String()
-	}
-
-	for service, status := range services {
-		isRunning := strings.Contains(strings.ToLower(status), "up") || strings.Contains(strings.ToLower(status), "running")
-		if isRunning {
-			sb.WriteString(fmt.Sprintf("%s🟢 %s: %srunning%s (%s)\n", ColorGreen, service, ColorGreen, ColorReset, status))
-		} else {
-			sb.WriteString(fmt.Sprintf("%s🔴 %s: %sstopped%s (%s)\n", ColorRed, service, ColorRed, ColorReset, status))
-		}
-	}
-
-	return sb.String()
-}
-
-// FormatActionResult formats the result of an action
-func (f *Formatter) FormatActionResult(result model.Result) string {
-	if result.Success {
-		return fmt.Sprintf("%s✅ %s%s", ColorGreen, result.Message, ColorReset)
-	}
-	return fmt.Sprintf("%s❌ %s: %v%s", ColorRed, result.Project.Name, result.Error, ColorReset)
-}
-
-// FormatProjectNotFound formats a message when a project is not found
-func (f *Formatter) FormatProjectNotFound(projectName string) string {
-	return fmt.Sprintf("%s❓ Project %s%s%s not found%s",
-		ColorYellow, ColorBold, projectName, ColorReset, ColorReset)
-}
+// Output formats supported by New
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatTable = "table"
+)
 
-// FormatActionStart formats the start of an action
-func (f *Formatter) FormatActionStart(actionName string, projectName string) string {
-	return fmt.Sprintf("%s🔄 %s Docker Compose project: %s%s%s",
-		ColorBold, actionName, ColorBlue, projectName, ColorReset)
+// Options configures how output is rendered by formatters that support it
+type Options struct {
+	// Columns selects the table columns to show, in order
+	Columns []string
+	// Sort is the column used to sort table rows
+	Sort string
+	// NoHeaders hides the table header row
+	NoHeaders bool
+	// NoColor disables ANSI colors in text output
+	NoColor bool
+	// NoEmoji disables emojis in text output
+	NoEmoji bool
+	// Theme styles text output, DefaultTheme is used when nil
+	Theme Theme
 }
 
-// FormatNoProjectsFound formats a message when no projects are found
-func (f *Formatter) FormatNoProjectsFound() string {
-	return fmt.Sprintf("%s❌ No Docker Compose projects found%s", ColorRed, ColorReset)
+// Formatter renders the results of dcm commands.
+// Methods returning an empty string produce no output for that format.
+type Formatter interface {
+	// FormatProjectList formats the list of discovered projects
+	FormatProjectList(projects []model.Project) string
+	// FormatManagedProjectsList formats the list of managed projects
+	FormatManagedProjectsList(projects []model.ManagedProject) string
+	// FormatProjectStatus formats the status of a single project
+	FormatProjectStatus(status model.ProjectStatus) string
+	// FormatStatusList formats the status of several projects
+	FormatStatusList(statuses []model.ProjectStatus) string
+	// FormatActionResult formats the result of a single action
+	FormatActionResult(result model.Result) string
+	// FormatActionResults formats the results of a bulk action
+	FormatActionResults(results []model.Result) string
+	// FormatActionStart formats the start of an action on one project
+	FormatActionStart(actionName string, projectName string) string
+	// FormatBulkActionStart formats the start of an action on several projects
+	FormatBulkActionStart(actionName string, count int) string
+	// FormatProjectNotFound formats a message when a project is not found
+	FormatProjectNotFound(projectName string) string
+	// FormatNoProjectsFound formats a message when no projects are found
+	FormatNoProjectsFound() string
+	// FormatManagedProjectAdded formats a message when a project becomes managed
+	FormatManagedProjectAdded(project model.ManagedProject) string
+	// FormatManagedProjectRemoved formats a message when a managed project is removed
+	FormatManagedProjectRemoved(project model.ManagedProject) string
+	// FormatMigration formats the outcome of a config migration
+	FormatMigration(result model.MigrationResult) string
+	// FormatConfigPath formats the location of the config file
+	FormatConfigPath(path string) string
+	// FormatConfig formats the effective config, given as YAML
+	FormatConfig(data []byte) string
+	// FormatConfigSaved formats the outcome of editing the config file
+	FormatConfigSaved(path string, changed bool) string
+	// FormatValidation formats the problems found in the config file
+	FormatValidation(result model.ValidationResult) string
+	// FormatInspection formats the effective settings of a project
+	FormatInspection(inspection model.ProjectInspection) string
+	// FormatContexts formats the list of dcm contexts
+	FormatContexts(contexts []model.ContextInfo) string
+	// FormatEngineProjects formats the compose projects known to the engine
+	FormatEngineProjects(projects []model.EngineProject) string
 }
 
-// FormatManagedProjectsList formats the list of managed projects
-func (f *Formatter) FormatManagedProjectsList(projects []model.ManagedProject) string {
-	if len(projects) == 0 {
-		return fmt.Sprintf("%s❌ No managed projects found%s", ColorYellow, ColorReset)
+// New returns the formatter for the given output format
+func New(format string, options Options) (Formatter, error) {
+	switch format {
+	case "", FormatText:
+		return NewTextFormatter(options), nil
+	case FormatJSON:
+		return NewJSONFormatter(), nil
+	case FormatYAML:
+		return NewYAMLFormatter(), nil
+	case FormatTable:
+		return NewTableFormatter(options)
+	default:
+		return nil, fmt.Errorf("unknown output format '%s' (valid formats: %s, %s, %s, %s)", format, FormatText, FormatJSON, FormatYAML, FormatTable)
 	}
-
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("%s📋 Managed Projects:%s\n", ColorBold, ColorReset))
-
-	for i, p := range projects {
-		sb.WriteString(fmt.Sprintf("%s📌 %d.%s %s%s%s (alias) -> %s%s%s (%s)\n",
-			ColorBlue, i+1, ColorReset,
-			ColorBold, p.Alias, ColorReset,
-			ColorGreen, p.Project.Name, ColorReset,
-			p.Project.Path))
-	}
-
-	return sb.String()
 }
@@ -0,0 +1,109 @@
+package formatter
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// update rewrites the golden files with the current output
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+var (
+	testProjects = []model.Project{
+		{Name: "api", Path: "/srv/api", File: "docker-compose.yml"},
+		{Name: "web", Path: "/srv/web", File: "compose.yaml", ProjectSettings: model.ProjectSettings{Profiles: []string{"dev"}}},
+	}
+
+	testManaged = []model.ManagedProject{
+		{Alias: "api", Project: testProjects[0]},
+		{Alias: "shop", Project: testProjects[1], Protected: true},
+	}
+
+	testResults = []model.Result{
+		{Project: testProjects[0], Success: true, Message: "Successfully started api"},
+		{Project: testProjects[1], Error: errors.New("error starting web: exit status 1"), Attempts: 3},
+	}
+
+	testStatuses = []model.ProjectStatus{
+		{
+			Project: testProjects[0],
+			Running: true,
+			Services: []model.ServiceStatus{
+				{Name: "app", State: "running", Status: "Up 5 minutes (healthy)", Running: true, Ports: []string{"0.0.0.0:8080->80/tcp"}, Health: "healthy"},
+				{Name: "migrate", State: "exited", Status: "Exited (0) 5 minutes ago", Exited: true},
+			},
+		},
+		{Project: testProjects[1], Error: errors.New("error checking status: no such file")},
+	}
+)
+
+// goldenCase renders one kind of output with a formatter
+type goldenCase struct {
+	name string
+	// template is the --format template used for this kind of output
+	template string
+	render   func(f Formatter) string
+}
+
+var goldenCases = []goldenCase{
+	{"projects", "{{.Name}}\t{{.Path}}", func(f Formatter) string { return f.FormatProjectList(testProjects) }},
+	{"managed", "{{.Alias}}\t{{.Project.Path}}\t{{.Protected}}", func(f Formatter) string { return f.FormatManagedProjectsList(testManaged) }},
+	{"results", "{{.Project.Name}}\t{{.Success}}\t{{.Attempts}}", func(f Formatter) string { return f.FormatActionResults(testResults) }},
+	{"status", "{{.Project.Name}}\t{{.Running}}\t{{len .Services}}", func(f Formatter) string { return f.FormatStatusList(testStatuses) }},
+	{"error", "{{.Name}}", func(f Formatter) string { return f.FormatProjectNotFound("ghost") }},
+}
+
+// goldenFormatters creates a formatter of each output format
+func goldenFormatters(t *testing.T, template string) map[string]Formatter {
+	t.Helper()
+	options := Options{NoColor: true, NoEmoji: true}
+
+	table, err := NewTableFormatter(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := NewTemplateFormatter(template, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]Formatter{
+		FormatText:  NewTextFormatter(options),
+		FormatJSON:  NewJSONFormatter(),
+		FormatYAML:  NewYAMLFormatter(),
+		FormatTable: table,
+		"template":  tmpl,
+	}
+}
+
+func TestGolden(t *testing.T) {
+	for _, c := range goldenCases {
+		for format, f := range goldenFormatters(t, c.template) {
+			c, f := c, f
+			t.Run(c.name+"/"+format, func(t *testing.T) {
+				got := c.render(f) + "\n"
+				path := filepath.Join("testdata", c.name+"."+format+".golden")
+
+				if *update {
+					if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("error reading golden file, run go test with -update to create it: %v", err)
+				}
+				if got != string(want) {
+					t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", path, got, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package formatter
+
+import "github.com/mitas/dcm/internal/model"
+
+// The types below define the stable schema used by the JSON and YAML
+// formatters. Fields are only ever added, never renamed or removed.
+
+// ProjectDoc describes a discovered docker-compose project
+type ProjectDoc struct {
+	Workspace      string            `json:"workspace,omitempty" yaml:"workspace,omitempty"`
+	Name           string            `json:"name" yaml:"name"`
+	Path           string            `json:"path" yaml:"path"`
+	File           string            `json:"file" yaml:"file"`
+	EnvFiles       []string          `json:"env_files,omitempty" yaml:"env_files,omitempty"`
+	Profiles       []string          `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+	ComposeFiles   []string          `json:"compose_files,omitempty" yaml:"compose_files,omitempty"`
+	DependsOn      []string          `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+	Hooks          *HooksDoc         `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+	DockerContext  string            `json:"docker_context,omitempty" yaml:"docker_context,omitempty"`
+	DockerHost     string            `json:"docker_host,omitempty" yaml:"docker_host,omitempty"`
+	Env            map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+	ComposeBackend string            `json:"compose_backend,omitempty" yaml:"compose_backend,omitempty"`
+}
+
+// HooksDoc lists the shell commands run around start and stop
+type HooksDoc struct {
+	PreStart  []string `json:"pre_start,omitempty" yaml:"pre_start,omitempty"`
+	PostStart []string `json:"post_start,omitempty" yaml:"post_start,omitempty"`
+	PreStop   []string `json:"pre_stop,omitempty" yaml:"pre_stop,omitempty"`
+	PostStop  []string `json:"post_stop,omitempty" yaml:"post_stop,omitempty"`
+}
+
+// InspectionDoc describes the effective settings of a project and their sources
+type InspectionDoc struct {
+	Alias       string            `json:"alias,omitempty" yaml:"alias,omitempty"`
+	Project     ProjectDoc        `json:"project" yaml:"project"`
+	LocalConfig string            `json:"local_config,omitempty" yaml:"local_config,omitempty"`
+	Sources     map[string]string `json:"sources" yaml:"sources"`
+}
+
+// ManagedProjectDoc describes a managed docker-compose project
+type ManagedProjectDoc struct {
+	Alias     string     `json:"alias" yaml:"alias"`
+	Project   ProjectDoc `json:"project" yaml:"project"`
+	Protected bool       `json:"protected" yaml:"protected"`
+}
+
+// ServiceDoc describes the state of a single service
+type ServiceDoc struct {
+	Name    string   `json:"name" yaml:"name"`
+	State   string   `json:"state" yaml:"state"`
+	Status  string   `json:"status" yaml:"status"`
+	Running bool     `json:"running" yaml:"running"`
+	Ports   []string `json:"ports" yaml:"ports"`
+	Health  string   `json:"health,omitempty" yaml:"health,omitempty"`
+	Exited  bool     `json:"exited,omitempty" yaml:"exited,omitempty"`
+	// ExitCode is only set once every container of the service has exited
+	ExitCode *int `json:"exit_code,omitempty" yaml:"exit_code,omitempty"`
+}
+
+// StatusDoc describes the state of a project and its services
+type StatusDoc struct {
+	Project ProjectDoc `json:"project" yaml:"project"`
+	// Target is the Docker context or host, empty for the ambient one
+	Target   string       `json:"target,omitempty" yaml:"target,omitempty"`
+	Running  bool         `json:"running" yaml:"running"`
+	Services []ServiceDoc `json:"services" yaml:"services"`
+	Error    string       `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// ResultDoc describes the outcome of an action on a project
+type ResultDoc struct {
+	Project ProjectDoc `json:"project" yaml:"project"`
+	Success bool       `json:"success" yaml:"success"`
+	Message string     `json:"message,omitempty" yaml:"message,omitempty"`
+	Error   string     `json:"error,omitempty" yaml:"error,omitempty"`
+	// Attempts is how many times the compose command ran, when it ran
+	Attempts int `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+}
+
+// ErrorDoc describes a failure that is not tied to a single action
+type ErrorDoc struct {
+	Error string `json:"error" yaml:"error"`
+}
+
+// MigrationDoc describes the upgrade of the config file to the current schema
+type MigrationDoc struct {
+	Path        string `json:"path" yaml:"path"`
+	FromVersion int    `json:"from_version" yaml:"from_version"`
+	ToVersion   int    `json:"to_version" yaml:"to_version"`
+	DryRun      bool   `json:"dry_run" yaml:"dry_run"`
+	Diff        string `json:"diff" yaml:"diff"`
+	Backup      string `json:"backup,omitempty" yaml:"backup,omitempty"`
+}
+
+// ConfigPathDoc describes the location of the config file
+type ConfigPathDoc struct {
+	Path string `json:"path" yaml:"path"`
+}
+
+// ConfigSavedDoc describes the outcome of editing the config file
+type ConfigSavedDoc struct {
+	Path    string `json:"path" yaml:"path"`
+	Changed bool   `json:"changed" yaml:"changed"`
+}
+
+// IssueDoc describes a problem with a managed project
+type IssueDoc struct {
+	Alias   string `json:"alias" yaml:"alias"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// ValidationDoc describes the outcome of validating the config file
+type ValidationDoc struct {
+	Path   string     `json:"path" yaml:"path"`
+	Valid  bool       `json:"valid" yaml:"valid"`
+	Issues []IssueDoc `json:"issues" yaml:"issues"`
+}
+
+// ContextDoc describes a dcm context
+type ContextDoc struct {
+	Name     string `json:"name" yaml:"name"`
+	Current  bool   `json:"current" yaml:"current"`
+	File     string `json:"file,omitempty" yaml:"file,omitempty"`
+	Projects int    `json:"projects" yaml:"projects"`
+}
+
+// EngineProjectDoc describes a compose project known to the engine
+type EngineProjectDoc struct {
+	Name        string      `json:"name" yaml:"name"`
+	Status      string      `json:"status" yaml:"status"`
+	WorkingDir  string      `json:"working_dir,omitempty" yaml:"working_dir,omitempty"`
+	ConfigFiles []string    `json:"config_files,omitempty" yaml:"config_files,omitempty"`
+	Alias       string      `json:"alias,omitempty" yaml:"alias,omitempty"`
+	Project     *ProjectDoc `json:"project,omitempty" yaml:"project,omitempty"`
+	Orphan      bool        `json:"orphan" yaml:"orphan"`
+}
+
+func newProjectDoc(p model.Project) ProjectDoc {
+	doc := ProjectDoc{
+		Workspace:      p.Workspace,
+		Name:           p.Name,
+		Path:           p.Path,
+		File:           p.File,
+		EnvFiles:       p.EnvFiles,
+		Profiles:       p.Profiles,
+		ComposeFiles:   p.ComposeFiles,
+		DependsOn:      p.DependsOn,
+		DockerContext:  p.DockerContext,
+		DockerHost:     p.DockerHost,
+		Env:            p.Env,
+		ComposeBackend: p.ComposeBackend,
+	}
+	if hooks := p.Hooks; len(hooks.PreStart)+len(hooks.PostStart)+len(hooks.PreStop)+len(hooks.PostStop) > 0 {
+		doc.Hooks = &HooksDoc{PreStart: hooks.PreStart, PostStart: hooks.PostStart, PreStop: hooks.PreStop, PostStop: hooks.PostStop}
+	}
+	return doc
+}
+
+func newManagedProjectDoc(p model.ManagedProject) ManagedProjectDoc {
+	return ManagedProjectDoc{Alias: p.Alias, Project: newProjectDoc(p.Project), Protected: p.Protected}
+}
+
+func newStatusDoc(s model.ProjectStatus) StatusDoc {
+	doc := StatusDoc{
+		Project:  newProjectDoc(s.Project),
+		Target:   s.Project.DockerTarget(),
+		Running:  s.Running,
+		Services: make([]ServiceDoc, 0, len(s.Services)),
+	}
+	for _, service := range s.Services {
+		ports := service.Ports
+		if ports == nil {
+			ports = []string{}
+		}
+		serviceDoc := ServiceDoc{
+			Name:    service.Name,
+			State:   service.State,
+			Status:  service.Status,
+			Running: service.Running,
+			Ports:   ports,
+			Health:  service.Health,
+			Exited:  service.Exited,
+		}
+		if service.Exited {
+			exitCode := service.ExitCode
+			serviceDoc.ExitCode = &exitCode
+		}
+		doc.Services = append(doc.Services, serviceDoc)
+	}
+	if s.Error != nil {
+		doc.Error = s.Error.Error()
+	}
+	return doc
+}
+
+func newResultDoc(r model.Result) ResultDoc {
+	doc := ResultDoc{
+		Project:  newProjectDoc(r.Project),
+		Success:  r.Success,
+		Message:  r.Message,
+		Attempts: r.Attempts,
+	}
+	if r.Error != nil {
+		doc.Error = r.Error.Error()
+	}
+	return doc
+}
+
+func newEngineProjectDoc(p model.EngineProject) EngineProjectDoc {
+	doc := EngineProjectDoc{
+		Name:        p.Name,
+		Status:      p.Status,
+		WorkingDir:  p.WorkingDir,
+		ConfigFiles: p.ConfigFiles,
+		Alias:       p.Alias,
+		Orphan:      p.Orphan(),
+	}
+	if p.Project != nil {
+		project := newProjectDoc(*p.Project)
+		doc.Project = &project
+	}
+	return doc
+}
@@ -0,0 +1,196 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// StructuredFormatter renders output as machine-readable documents.
+// Progress messages are omitted so the output stays parseable.
+type StructuredFormatter struct {
+	marshal func(v interface{}) ([]byte, error)
+}
+
+// NewJSONFormatter creates a formatter producing indented JSON
+func NewJSONFormatter() *StructuredFormatter {
+	return &StructuredFormatter{
+		marshal: func(v interface{}) ([]byte, error) {
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			err := enc.Encode(v)
+			return buf.Bytes(), err
+		},
+	}
+}
+
+// NewYAMLFormatter creates a formatter producing YAML
+func NewYAMLFormatter() *StructuredFormatter {
+	return &StructuredFormatter{
+		marshal: yaml.Marshal,
+	}
+}
+
+// render marshals a document, falling back to an error document on failure
+func (f *StructuredFormatter) render(v interface{}) string {
+	data, err := f.marshal(v)
+	if err != nil {
+		data, _ = f.marshal(ErrorDoc{Error: fmt.Sprintf("error serializing output: %v", err)})
+	}
+	return strings.TrimRight(string(data), "\n")
+}
+
+// FormatProjectList formats the list of projects as an array of ProjectDoc
+func (f *StructuredFormatter) FormatProjectList(projects []model.Project) string {
+	docs := make([]ProjectDoc, 0, len(projects))
+	for _, p := range projects {
+		docs = append(docs, newProjectDoc(p))
+	}
+	return f.render(docs)
+}
+
+// FormatManagedProjectsList formats the managed projects as an array of ManagedProjectDoc
+func (f *StructuredFormatter) FormatManagedProjectsList(projects []model.ManagedProject) string {
+	docs := make([]ManagedProjectDoc, 0, len(projects))
+	for _, p := range projects {
+		docs = append(docs, newManagedProjectDoc(p))
+	}
+	return f.render(docs)
+}
+
+// FormatProjectStatus formats the status of a project as a StatusDoc
+func (f *StructuredFormatter) FormatProjectStatus(status model.ProjectStatus) string {
+	return f.render(newStatusDoc(status))
+}
+
+// FormatStatusList formats the status of several projects as an array of StatusDoc
+func (f *StructuredFormatter) FormatStatusList(statuses []model.ProjectStatus) string {
+	docs := make([]StatusDoc, 0, len(statuses))
+	for _, s := range statuses {
+		docs = append(docs, newStatusDoc(s))
+	}
+	return f.render(docs)
+}
+
+// FormatActionResult formats the result of an action as a ResultDoc
+func (f *StructuredFormatter) FormatActionResult(result model.Result) string {
+	return f.render(newResultDoc(result))
+}
+
+// FormatActionResults formats the results of a bulk action as an array of ResultDoc
+func (f *StructuredFormatter) FormatActionResults(results []model.Result) string {
+	docs := make([]ResultDoc, 0, len(results))
+	for _, r := range results {
+		docs = append(docs, newResultDoc(r))
+	}
+	return f.render(docs)
+}
+
+// FormatActionStart produces no output
+func (f *StructuredFormatter) FormatActionStart(actionName string, projectName string) string {
+	return ""
+}
+
+// FormatBulkActionStart produces no output
+func (f *StructuredFormatter) FormatBulkActionStart(actionName string, count int) string {
+	return ""
+}
+
+// FormatProjectNotFound formats an ErrorDoc for the missing project
+func (f *StructuredFormatter) FormatProjectNotFound(projectName string) string {
+	return f.render(ErrorDoc{Error: fmt.Sprintf("project '%s' not found", projectName)})
+}
+
+// FormatNoProjectsFound formats an empty array
+func (f *StructuredFormatter) FormatNoProjectsFound() string {
+	return f.render([]ProjectDoc{})
+}
+
+// FormatManagedProjectAdded formats the new managed project as a ManagedProjectDoc
+func (f *StructuredFormatter) FormatManagedProjectAdded(project model.ManagedProject) string {
+	return f.render(newManagedProjectDoc(project))
+}
+
+// FormatManagedProjectRemoved formats the removed project as a ManagedProjectDoc
+func (f *StructuredFormatter) FormatManagedProjectRemoved(project model.ManagedProject) string {
+	return f.render(newManagedProjectDoc(project))
+}
+
+// FormatMigration formats the outcome of a config migration as a MigrationDoc
+func (f *StructuredFormatter) FormatMigration(result model.MigrationResult) string {
+	return f.render(MigrationDoc{
+		Path:        result.Path,
+		FromVersion: result.FromVersion,
+		ToVersion:   result.ToVersion,
+		DryRun:      result.DryRun,
+		Diff:        result.Diff,
+		Backup:      result.Backup,
+	})
+}
+
+// FormatConfigPath formats the location of the config file as a ConfigPathDoc
+func (f *StructuredFormatter) FormatConfigPath(path string) string {
+	return f.render(ConfigPathDoc{Path: path})
+}
+
+// FormatConfig formats the effective config as a document with the same keys as the file
+func (f *StructuredFormatter) FormatConfig(data []byte) string {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return f.render(ErrorDoc{Error: fmt.Sprintf("error parsing config: %v", err)})
+	}
+	return f.render(doc)
+}
+
+// FormatConfigSaved formats the outcome of editing the config file as a ConfigSavedDoc
+func (f *StructuredFormatter) FormatConfigSaved(path string, changed bool) string {
+	return f.render(ConfigSavedDoc{Path: path, Changed: changed})
+}
+
+// FormatValidation formats the problems found in the config file as a ValidationDoc
+func (f *StructuredFormatter) FormatValidation(result model.ValidationResult) string {
+	doc := ValidationDoc{
+		Path:   result.Path,
+		Valid:  len(result.Issues) == 0,
+		Issues: make([]IssueDoc, 0, len(result.Issues)),
+	}
+	for _, issue := range result.Issues {
+		doc.Issues = append(doc.Issues, IssueDoc{Alias: issue.Alias, Message: issue.Message})
+	}
+	return f.render(doc)
+}
+
+// FormatInspection formats the effective settings of a project as an InspectionDoc
+func (f *StructuredFormatter) FormatInspection(inspection model.ProjectInspection) string {
+	return f.render(InspectionDoc{
+		Alias:       inspection.Alias,
+		Project:     newProjectDoc(inspection.Project),
+		LocalConfig: inspection.LocalConfig,
+		Sources:     inspection.Sources,
+	})
+}
+
+// FormatContexts formats the contexts as an array of ContextDoc
+func (f *StructuredFormatter) FormatContexts(contexts []model.ContextInfo) string {
+	docs := make([]ContextDoc, 0, len(contexts))
+	for _, c := range contexts {
+		docs = append(docs, ContextDoc{Name: c.Name, Current: c.Current, File: c.File, Projects: c.Projects})
+	}
+	return f.render(docs)
+}
+
+// FormatEngineProjects formats the engine projects as an array of EngineProjectDoc
+func (f *StructuredFormatter) FormatEngineProjects(projects []model.EngineProject) string {
+	docs := make([]EngineProjectDoc, 0, len(projects))
+	for _, p := range projects {
+		docs = append(docs, newEngineProjectDoc(p))
+	}
+	return f.render(docs)
+}
@@ -0,0 +1,231 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// Columns supported by the table formatter
+const (
+	ColumnAlias     = "alias"
+	ColumnWorkspace = "workspace"
+	ColumnName      = "name"
+	ColumnPath      = "path"
+	ColumnFile      = "file"
+	ColumnState     = "state"
+	ColumnServices  = "services"
+	ColumnPorts     = "ports"
+	ColumnTarget    = "target"
+	ColumnProject   = "project"
+)
+
+// TableColumns lists every column the table formatter can render
+var TableColumns = []string{ColumnAlias, ColumnWorkspace, ColumnName, ColumnPath, ColumnFile, ColumnState, ColumnServices, ColumnPorts, ColumnTarget, ColumnProject}
+
+// Default columns for each kind of table
+var (
+	projectColumns = []string{ColumnName, ColumnPath, ColumnFile}
+	managedColumns = []string{ColumnAlias, ColumnName, ColumnPath}
+	statusColumns  = []string{ColumnName, ColumnState, ColumnServices, ColumnPorts}
+	engineColumns  = []string{ColumnName, ColumnState, ColumnProject, ColumnPath}
+)
+
+// emptyCell is shown for values that do not apply to a row
+const emptyCell = "-"
+
+// tableRow holds the cell values of a row keyed by column
+type tableRow map[string]string
+
+// TableFormatter renders lists as aligned columns.
+// Messages that are not lists fall back to the text formatter.
+type TableFormatter struct {
+	*TextFormatter
+	options Options
+}
+
+// NewTableFormatter creates a table formatter, validating the selected columns
+func NewTableFormatter(options Options) (*TableFormatter, error) {
+	for _, column := range options.Columns {
+		if !isTableColumn(column) {
+			return nil, fmt.Errorf("unknown column '%s' (valid columns: %s)", column, strings.Join(TableColumns, ", "))
+		}
+	}
+	if options.Sort != "" && !isTableColumn(options.Sort) {
+		return nil, fmt.Errorf("unknown sort column '%s' (valid columns: %s)", options.Sort, strings.Join(TableColumns, ", "))
+	}
+
+	return &TableFormatter{
+		TextFormatter: NewTextFormatter(options),
+		options:       options,
+	}, nil
+}
+
+// isTableColumn reports whether column is a known table column
+func isTableColumn(column string) bool {
+	for _, c := range TableColumns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// render writes rows as an aligned table using the selected or default columns
+func (f *TableFormatter) render(rows []tableRow, defaultColumns []string) string {
+	columns := f.options.Columns
+	if len(columns) == 0 {
+		columns = defaultColumns
+	}
+
+	if f.options.Sort != "" {
+		sort.SliceStable(rows, func(i, j int) bool {
+			return rows[i][f.options.Sort] < rows[j][f.options.Sort]
+		})
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 3, ' ', 0)
+
+	if !f.options.NoHeaders {
+		headers := make([]string, len(columns))
+		for i, column := range columns {
+			headers[i] = strings.ToUpper(column)
+		}
+		fmt.Fprintln(w, strings.Join(headers, "\t"))
+	}
+
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = row[column]
+			if cells[i] == "" {
+				cells[i] = emptyCell
+			}
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+
+	w.Flush()
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// projectRow returns the cells describing a project
+func projectRow(p model.Project) tableRow {
+	return tableRow{
+		ColumnWorkspace: p.Workspace,
+		ColumnName:      p.Name,
+		ColumnPath:      p.Path,
+		ColumnFile:      p.File,
+		ColumnTarget:    p.DockerTarget(),
+	}
+}
+
+// FormatProjectList formats the list of projects as a table
+func (f *TableFormatter) FormatProjectList(projects []model.Project) string {
+	rows := make([]tableRow, 0, len(projects))
+	columns := projectColumns
+	for _, p := range projects {
+		rows = append(rows, projectRow(p))
+		// Show the workspace column as soon as one project has a workspace
+		if p.Workspace != "" && len(columns) == len(projectColumns) {
+			columns = append([]string{ColumnWorkspace}, projectColumns...)
+		}
+	}
+	return f.render(rows, columns)
+}
+
+// FormatManagedProjectsList formats the list of managed projects as a table
+func (f *TableFormatter) FormatManagedProjectsList(projects []model.ManagedProject) string {
+	rows := make([]tableRow, 0, len(projects))
+	for _, p := range projects {
+		row := projectRow(p.Project)
+		row[ColumnAlias] = p.Alias
+		rows = append(rows, row)
+	}
+	return f.render(rows, managedColumns)
+}
+
+// statusRow returns the cells describing a project and its services
+func statusRow(s model.ProjectStatus) tableRow {
+	row := projectRow(s.Project)
+
+	switch {
+	case s.Error != nil:
+		row[ColumnState] = "error"
+	case s.Running:
+		row[ColumnState] = "running"
+	default:
+		row[ColumnState] = "stopped"
+	}
+
+	running := 0
+	var ports []string
+	for _, service := range s.Services {
+		if service.Running {
+			running++
+		}
+		ports = append(ports, service.Ports...)
+	}
+	row[ColumnServices] = fmt.Sprintf("%d/%d", running, len(s.Services))
+	row[ColumnPorts] = strings.Join(ports, ",")
+
+	return row
+}
+
+// FormatProjectStatus formats the status of a project as a single-row table
+func (f *TableFormatter) FormatProjectStatus(status model.ProjectStatus) string {
+	return f.FormatStatusList([]model.ProjectStatus{status})
+}
+
+// FormatStatusList formats the status of several projects as a table
+func (f *TableFormatter) FormatStatusList(statuses []model.ProjectStatus) string {
+	rows := make([]tableRow, 0, len(statuses))
+	columns := statusColumns
+	for _, s := range statuses {
+		rows = append(rows, statusRow(s))
+		// Show the target column as soon as one project runs on another engine
+		if s.Project.DockerTarget() != "" && len(columns) == len(statusColumns) {
+			columns = append(append([]string{}, statusColumns...), ColumnTarget)
+		}
+	}
+	return f.render(rows, columns)
+}
+
+// FormatEngineProjects formats the compose projects known to the engine as
+// a table. The project column names the matching alias or project, or
+// marks the project as an orphan.
+func (f *TableFormatter) FormatEngineProjects(projects []model.EngineProject) string {
+	rows := make([]tableRow, 0, len(projects))
+	for _, p := range projects {
+		row := tableRow{
+			ColumnName:  p.Name,
+			ColumnState: p.Status,
+			ColumnPath:  p.WorkingDir,
+			ColumnAlias: p.Alias,
+		}
+		switch {
+		case p.Alias != "":
+			row[ColumnProject] = p.Alias
+		case !p.Orphan():
+			row[ColumnProject] = p.Project.Name
+		default:
+			row[ColumnProject] = "(orphan)"
+		}
+		rows = append(rows, row)
+	}
+	return f.render(rows, engineColumns)
+}
+
+// FormatActionStart produces no output so only the table is printed
+func (f *TableFormatter) FormatActionStart(actionName string, projectName string) string {
+	return ""
+}
+
+// FormatBulkActionStart produces no output so only the table is printed
+func (f *TableFormatter) FormatBulkActionStart(actionName string, count int) string {
+	return ""
+}
@@ -0,0 +1,154 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// templateFuncs are the helper functions available to --format templates
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper": strings.ToUpper,
+	"join": func(elems []string, sep string) string {
+		return strings.Join(elems, sep)
+	},
+	"relpath": func(path string) string {
+		wd, err := os.Getwd()
+		if err != nil {
+			return path
+		}
+		rel, err := filepath.Rel(wd, path)
+		if err != nil {
+			return path
+		}
+		return rel
+	},
+}
+
+// TemplateFormatter renders each item of a list with a Go template, like
+// `docker ps --format`. Items are model.Project, model.ManagedProject,
+// model.ProjectStatus or model.Result depending on the command.
+// Messages that are not lists fall back to the text formatter.
+type TemplateFormatter struct {
+	*TextFormatter
+	tmpl *template.Template
+}
+
+// NewTemplateFormatter parses format and creates a template formatter.
+// The escape sequences \t and \n are expanded so they can be typed in a shell.
+func NewTemplateFormatter(format string, options Options) (*TemplateFormatter, error) {
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing format template: %w", err)
+	}
+
+	return &TemplateFormatter{
+		TextFormatter: NewTextFormatter(options),
+		tmpl:          tmpl,
+	}, nil
+}
+
+// execute renders a single item, reporting template errors inline
+func (f *TemplateFormatter) execute(item interface{}) string {
+	var buf bytes.Buffer
+	if err := f.tmpl.Execute(&buf, item); err != nil {
+		return fmt.Sprintf("error executing format template: %v", err)
+	}
+	return buf.String()
+}
+
+// FormatProjectList renders the template once per model.Project
+func (f *TemplateFormatter) FormatProjectList(projects []model.Project) string {
+	lines := make([]string, 0, len(projects))
+	for _, p := range projects {
+		lines = append(lines, f.execute(p))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatManagedProjectsList renders the template once per model.ManagedProject
+func (f *TemplateFormatter) FormatManagedProjectsList(projects []model.ManagedProject) string {
+	lines := make([]string, 0, len(projects))
+	for _, p := range projects {
+		lines = append(lines, f.execute(p))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatProjectStatus renders the template for a model.ProjectStatus
+func (f *TemplateFormatter) FormatProjectStatus(status model.ProjectStatus) string {
+	return f.execute(status)
+}
+
+// FormatStatusList renders the template once per model.ProjectStatus
+func (f *TemplateFormatter) FormatStatusList(statuses []model.ProjectStatus) string {
+	lines := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		lines = append(lines, f.execute(s))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatActionResult renders the template for a model.Result
+func (f *TemplateFormatter) FormatActionResult(result model.Result) string {
+	return f.execute(result)
+}
+
+// FormatActionResults renders the template once per model.Result
+func (f *TemplateFormatter) FormatActionResults(results []model.Result) string {
+	lines := make([]string, 0, len(results))
+	for _, r := range results {
+		lines = append(lines, f.execute(r))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatActionStart produces no output so only the template output is printed
+func (f *TemplateFormatter) FormatActionStart(actionName string, projectName string) string {
+	return ""
+}
+
+// FormatBulkActionStart produces no output so only the template output is printed
+func (f *TemplateFormatter) FormatBulkActionStart(actionName string, count int) string {
+	return ""
+}
+
+// FormatNoProjectsFound produces no output so an empty list prints nothing
+func (f *TemplateFormatter) FormatNoProjectsFound() string {
+	return ""
+}
+
+// FormatInspection renders the template for a model.ProjectInspection
+func (f *TemplateFormatter) FormatInspection(inspection model.ProjectInspection) string {
+	return f.execute(inspection)
+}
+
+// FormatContexts renders the template once per model.ContextInfo
+func (f *TemplateFormatter) FormatContexts(contexts []model.ContextInfo) string {
+	lines := make([]string, 0, len(contexts))
+	for _, c := range contexts {
+		lines = append(lines, f.execute(c))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatEngineProjects renders the template once per model.EngineProject
+func (f *TemplateFormatter) FormatEngineProjects(projects []model.EngineProject) string {
+	lines := make([]string, 0, len(projects))
+	for _, p := range projects {
+		lines = append(lines, f.execute(p))
+	}
+	return strings.Join(lines, "\n")
+}
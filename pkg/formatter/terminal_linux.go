@@ -0,0 +1,5 @@
+package formatter
+
+import "syscall"
+
+const ioctlReadTermios = syscall.TCGETS
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package formatter
+
+// isTerminal reports no terminals where dcm cannot ask the system
+func isTerminal(fd uintptr) bool {
+	return false
+}
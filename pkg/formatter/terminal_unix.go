@@ -0,0 +1,16 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package formatter
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// isTerminal reports whether fd is a terminal by reading its terminal
+// attributes, which fails for other character devices such as /dev/null
+func isTerminal(fd uintptr) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlReadTermios, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
@@ -0,0 +1,9 @@
+package formatter
+
+import "syscall"
+
+// isTerminal reports whether fd is a console, which has a console mode
+func isTerminal(fd uintptr) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(fd), &mode) == nil
+}
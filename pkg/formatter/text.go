@@ -0,0 +1,396 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mitas/dcm/internal/model"
+)
+
+// TextFormatter handles human-readable output with colors and emojis
+type TextFormatter struct {
+	theme   Theme
+	noColor bool
+	noEmoji bool
+}
+
+// NewTextFormatter creates a new text formatter
+func NewTextFormatter(options Options) *TextFormatter {
+	theme := options.Theme
+	if theme == nil {
+		theme = DefaultTheme
+	}
+	return &TextFormatter{
+		theme:   theme,
+		noColor: options.NoColor,
+		noEmoji: options.NoEmoji,
+	}
+}
+
+// c returns the theme color of a role, or nothing when colors are disabled
+func (f *TextFormatter) c(role string) string {
+	if f.noColor {
+		return ""
+	}
+	return f.theme[role].Color
+}
+
+// raw returns an ANSI code, or nothing when colors are disabled
+func (f *TextFormatter) raw(code string) string {
+	if f.noColor {
+		return ""
+	}
+	return code
+}
+
+// icon returns the theme icon of a role followed by a space,
+// or nothing when emojis are disabled or the role has no icon
+func (f *TextFormatter) icon(role string) string {
+	icon := f.theme[role].Icon
+	if f.noEmoji || icon == "" {
+		return ""
+	}
+	return icon + " "
+}
+
+// FormatProjectList formats the list of projects for display
+func (f *TextFormatter) FormatProjectList(projects []model.Project) string {
+	if len(projects) == 0 {
+		return f.FormatNoProjectsFound()
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s%sFound %s%d%s Docker Compose projects:%s\n",
+		f.c(RoleHeader), f.icon(RoleHeader), f.c(RoleSuccess), len(projects), f.raw(ColorReset), f.raw(ColorReset)))
+
+	for i, project := range projects {
+		workspace := ""
+		if project.Workspace != "" {
+			workspace = fmt.Sprintf(" [%s]", project.Workspace)
+		}
+		sb.WriteString(fmt.Sprintf("%s%s%d.%s %s%s%s%s (%s%s/%s%s)\n",
+			f.c(RoleProject), f.icon(RoleProject), i+1, f.raw(ColorReset),
+			f.raw(ColorBold), project.Name, f.raw(ColorReset), workspace,
+			f.c(RolePath), project.Path, project.File, f.raw(ColorReset)))
+	}
+
+	return sb.String()
+}
+
+// FormatProjectStatus formats the status of a project
+func (f *TextFormatter) FormatProjectStatus(status model.ProjectStatus) string {
+	if status.Error != nil {
+		return fmt.Sprintf("%s%sError checking status of %s: %v%s",
+			f.c(RoleError), f.icon(RoleError), status.Project.Name, status.Error, f.raw(ColorReset))
+	}
+
+	var sb strings.Builder
+
+	target := ""
+	if t := status.Project.DockerTarget(); t != "" {
+		target = " on " + t
+	}
+	sb.WriteString(fmt.Sprintf("\n%s=== Status of %s%s%s (%s%s%s)%s ===%s\n",
+		f.c(RoleHeader), f.c(RoleProject), status.Project.Name, f.raw(ColorReset), f.c(RolePath), status.Project.Path, f.raw(ColorReset)+f.c(RoleHeader), target, f.raw(ColorReset)))
+
+	if len(status.Services) == 0 {
+		sb.WriteString(fmt.Sprintf("%s%sProject is not running (no containers)%s\n", f.c(RoleIdle), f.icon(RoleIdle), f.raw(ColorReset)))
+		return sb.String()
+	}
+
+	for _, service := range status.Services {
+		if service.Running {
+			sb.WriteString(fmt.Sprintf("%s%s%s: %srunning%s (%s)\n", f.c(RoleRunning), f.icon(RoleRunning), service.Name, f.c(RoleRunning), f.raw(ColorReset), service.Status))
+		} else {
+			sb.WriteString(fmt.Sprintf("%s%s%s: %sstopped%s (%s)\n", f.c(RoleStopped), f.icon(RoleStopped), service.Name, f.c(RoleStopped), f.raw(ColorReset), service.Status))
+		}
+	}
+
+	return sb.String()
+}
+
+// FormatStatusList formats the status of several projects
+func (f *TextFormatter) FormatStatusList(statuses []model.ProjectStatus) string {
+	if len(statuses) == 0 {
+		return f.FormatNoProjectsFound()
+	}
+
+	lines := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		lines = append(lines, f.FormatProjectStatus(status))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatActionResult formats the result of an action
+func (f *TextFormatter) FormatActionResult(result model.Result) string {
+	if result.Success {
+		return fmt.Sprintf("%s%s%s%s", f.c(RoleSuccess), f.icon(RoleSuccess), result.Message, f.raw(ColorReset))
+	}
+	return fmt.Sprintf("%s%s%s: %v%s", f.c(RoleError), f.icon(RoleError), result.Project.Name, result.Error, f.raw(ColorReset))
+}
+
+// FormatActionResults formats the results of a bulk action
+func (f *TextFormatter) FormatActionResults(results []model.Result) string {
+	lines := make([]string, 0, len(results))
+	for _, result := range results {
+		lines = append(lines, f.FormatActionResult(result))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatProjectNotFound formats a message when a project is not found
+func (f *TextFormatter) FormatProjectNotFound(projectName string) string {
+	return fmt.Sprintf("%s%sProject %s%s%s not found%s",
+		f.c(RoleWarning), f.icon(RoleWarning), f.raw(ColorBold), projectName, f.raw(ColorReset), f.raw(ColorReset))
+}
+
+// FormatActionStart formats the start of an action
+func (f *TextFormatter) FormatActionStart(actionName string, projectName string) string {
+	return fmt.Sprintf("%s%s%s Docker Compose project: %s%s%s",
+		f.c(RoleProgress), f.icon(RoleProgress), actionName, f.c(RoleProject), projectName, f.raw(ColorReset))
+}
+
+// FormatBulkActionStart formats the start of an action on several projects
+func (f *TextFormatter) FormatBulkActionStart(actionName string, count int) string {
+	return fmt.Sprintf("%s%s%s %s%d%s Docker Compose projects...%s",
+		f.c(RoleProgress), f.icon(RoleProgress), actionName, f.c(RoleSuccess), count, f.raw(ColorReset), f.raw(ColorReset))
+}
+
+// FormatNoProjectsFound formats a message when no projects are found
+func (f *TextFormatter) FormatNoProjectsFound() string {
+	return fmt.Sprintf("%s%sNo Docker Compose projects found%s", f.c(RoleError), f.icon(RoleError), f.raw(ColorReset))
+}
+
+// FormatManagedProjectsList formats the list of managed projects
+func (f *TextFormatter) FormatManagedProjectsList(projects []model.ManagedProject) string {
+	if len(projects) == 0 {
+		return fmt.Sprintf("%s%sNo managed projects found%s", f.c(RoleWarning), f.icon(RoleWarning), f.raw(ColorReset))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s%sManaged Projects:%s\n", f.c(RoleHeader), f.icon(RoleHeader), f.raw(ColorReset)))
+
+	for i, p := range projects {
+		protected := ""
+		if p.Protected {
+			protected = " [protected]"
+		}
+		sb.WriteString(fmt.Sprintf("%s%s%d.%s %s%s%s (alias) -> %s%s%s (%s%s%s)%s\n",
+			f.c(RoleManaged), f.icon(RoleManaged), i+1, f.raw(ColorReset),
+			f.raw(ColorBold), p.Alias, f.raw(ColorReset),
+			f.c(RoleProject), p.Project.Name, f.raw(ColorReset),
+			f.c(RolePath), p.Project.Path, f.raw(ColorReset), protected))
+	}
+
+	return sb.String()
+}
+
+// FormatManagedProjectAdded formats a message when a project becomes managed
+func (f *TextFormatter) FormatManagedProjectAdded(project model.ManagedProject) string {
+	return fmt.Sprintf("%s%sProject '%s%s%s' added to managed projects with alias '%s%s%s'%s",
+		f.c(RoleSuccess), f.icon(RoleSuccess),
+		f.raw(ColorBold), project.Project.Name, f.raw(ColorReset)+f.c(RoleSuccess),
+		f.raw(ColorBold), project.Alias, f.raw(ColorReset)+f.c(RoleSuccess),
+		f.raw(ColorReset))
+}
+
+// FormatManagedProjectRemoved formats a message when a managed project is removed
+func (f *TextFormatter) FormatManagedProjectRemoved(project model.ManagedProject) string {
+	return fmt.Sprintf("%s%sProject with alias '%s%s%s' removed from managed projects%s",
+		f.c(RoleSuccess), f.icon(RoleSuccess),
+		f.raw(ColorBold), project.Alias, f.raw(ColorReset)+f.c(RoleSuccess),
+		f.raw(ColorReset))
+}
+
+// FormatMigration formats the outcome of a config migration, including the diff
+func (f *TextFormatter) FormatMigration(result model.MigrationResult) string {
+	if result.FromVersion == result.ToVersion {
+		return fmt.Sprintf("%s%sConfig %s%s%s is up to date (version %d)%s",
+			f.c(RoleSuccess), f.icon(RoleSuccess), f.c(RolePath), result.Path, f.raw(ColorReset)+f.c(RoleSuccess), result.ToVersion, f.raw(ColorReset))
+	}
+
+	var sb strings.Builder
+	if result.DryRun {
+		sb.WriteString(fmt.Sprintf("%s%sConfig %s%s%s would be migrated from version %d to %d:%s\n",
+			f.c(RoleProgress), f.icon(RoleProgress), f.c(RolePath), result.Path, f.raw(ColorReset)+f.c(RoleProgress), result.FromVersion, result.ToVersion, f.raw(ColorReset)))
+	} else {
+		sb.WriteString(fmt.Sprintf("%s%sConfig %s%s%s migrated from version %d to %d, previous version saved to %s%s\n",
+			f.c(RoleSuccess), f.icon(RoleSuccess), f.c(RolePath), result.Path, f.raw(ColorReset)+f.c(RoleSuccess), result.FromVersion, result.ToVersion, result.Backup, f.raw(ColorReset)))
+	}
+
+	for _, line := range strings.Split(result.Diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			sb.WriteString(f.c(RoleSuccess) + line + f.raw(ColorReset) + "\n")
+		case strings.HasPrefix(line, "-"):
+			sb.WriteString(f.c(RoleError) + line + f.raw(ColorReset) + "\n")
+		default:
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// FormatConfigPath formats the location of the config file without decoration,
+// so it can be used in shell substitutions
+func (f *TextFormatter) FormatConfigPath(path string) string {
+	return path
+}
+
+// FormatConfig formats the effective config as YAML
+func (f *TextFormatter) FormatConfig(data []byte) string {
+	return strings.TrimRight(string(data), "\n")
+}
+
+// FormatConfigSaved formats the outcome of editing the config file
+func (f *TextFormatter) FormatConfigSaved(path string, changed bool) string {
+	if !changed {
+		return fmt.Sprintf("%s%sNo changes made to %s%s", f.c(RoleWarning), f.icon(RoleWarning), path, f.raw(ColorReset))
+	}
+	return fmt.Sprintf("%s%sConfig saved to %s%s", f.c(RoleSuccess), f.icon(RoleSuccess), path, f.raw(ColorReset))
+}
+
+// FormatValidation formats the problems found in the config file
+func (f *TextFormatter) FormatValidation(result model.ValidationResult) string {
+	if len(result.Issues) == 0 {
+		return fmt.Sprintf("%s%sConfig %s is valid%s", f.c(RoleSuccess), f.icon(RoleSuccess), result.Path, f.raw(ColorReset))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s%sConfig %s has %d problem(s):%s\n",
+		f.c(RoleHeader), f.icon(RoleHeader), result.Path, len(result.Issues), f.raw(ColorReset)))
+	for _, issue := range result.Issues {
+		sb.WriteString(fmt.Sprintf("%s%s%s%s%s: %s%s\n",
+			f.c(RoleError), f.icon(RoleError), f.raw(ColorBold), issue.Alias, f.raw(ColorReset)+f.c(RoleError), issue.Message, f.raw(ColorReset)))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// FormatInspection formats the effective settings of a project, naming the
+// layer each setting comes from
+func (f *TextFormatter) FormatInspection(inspection model.ProjectInspection) string {
+	project := inspection.Project
+
+	var sb strings.Builder
+	title := project.Name
+	if inspection.Alias != "" {
+		title = fmt.Sprintf("%s (alias %s)", project.Name, inspection.Alias)
+	}
+	sb.WriteString(fmt.Sprintf("%s%s%s%s\n", f.c(RoleHeader), f.icon(RoleHeader), title, f.raw(ColorReset)))
+
+	local := inspection.LocalConfig
+	if local == "" {
+		local = "none"
+	}
+	rows := []struct {
+		label, key string
+		values     []string
+	}{
+		{"path", "", []string{project.Path}},
+		{"compose file", "", []string{project.File}},
+		{"local config", "", []string{local}},
+		{"compose files", "compose_files", project.ComposeFiles},
+		{"env files", "env_files", project.EnvFiles},
+		{"profiles", "profiles", project.Profiles},
+		{"depends on", "depends_on", project.DependsOn},
+		{"pre_start", "hooks.pre_start", project.Hooks.PreStart},
+		{"post_start", "hooks.post_start", project.Hooks.PostStart},
+		{"pre_stop", "hooks.pre_stop", project.Hooks.PreStop},
+		{"post_stop", "hooks.post_stop", project.Hooks.PostStop},
+		{"docker context", "docker_context", nonEmptyValues(project.DockerContext)},
+		{"docker host", "docker_host", nonEmptyValues(project.DockerHost)},
+		{"env", "env", envValues(project.Env)},
+		{"compose backend", "compose_backend", nonEmptyValues(project.ComposeBackend)},
+	}
+
+	for _, row := range rows {
+		value := strings.Join(row.values, ", ")
+		if value == "" {
+			value = "-"
+		}
+		source := ""
+		if s := inspection.Sources[row.key]; s != "" {
+			source = fmt.Sprintf(" %s(%s)%s", f.c(RoleWarning), s, f.raw(ColorReset))
+		}
+		sb.WriteString(fmt.Sprintf("  %-16s %s%s%s%s\n", row.label+":", f.c(RolePath), value, f.raw(ColorReset), source))
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// FormatContexts formats the list of contexts, marking the current one
+func (f *TextFormatter) FormatContexts(contexts []model.ContextInfo) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s%sContexts:%s\n", f.c(RoleHeader), f.icon(RoleHeader), f.raw(ColorReset)))
+
+	for _, c := range contexts {
+		marker := " "
+		if c.Current {
+			marker = "*"
+		}
+		details := fmt.Sprintf("%d project(s)", c.Projects)
+		if c.File != "" {
+			details += ", " + f.c(RolePath) + c.File + f.raw(ColorReset)
+		}
+		sb.WriteString(fmt.Sprintf("%s %s%s%s (%s)\n", marker, f.raw(ColorBold), c.Name, f.raw(ColorReset), details))
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// nonEmptyValues returns value as a list, empty when value is empty
+func nonEmptyValues(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// envValues returns environment variables as KEY=value, sorted by name
+func envValues(env map[string]string) []string {
+	values := make([]string, 0, len(env))
+	for key, value := range env {
+		values = append(values, key+"="+value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// FormatEngineProjects formats the compose projects known to the engine,
+// showing the project each one belongs to and how to stop orphans
+func (f *TextFormatter) FormatEngineProjects(projects []model.EngineProject) string {
+	if len(projects) == 0 {
+		return fmt.Sprintf("%s%sNo compose projects known to the engine%s", f.c(RoleIdle), f.icon(RoleIdle), f.raw(ColorReset))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s%sCompose projects:%s\n", f.c(RoleHeader), f.icon(RoleHeader), f.raw(ColorReset)))
+
+	for _, p := range projects {
+		role := RoleStopped
+		if strings.Contains(p.Status, "running") {
+			role = RoleRunning
+		}
+		sb.WriteString(fmt.Sprintf("%s%s%s%s: %s", f.c(role), f.icon(role), p.Name, f.raw(ColorReset), p.Status))
+
+		switch {
+		case p.Alias != "":
+			sb.WriteString(fmt.Sprintf(" -> %s (alias) %s%s%s", p.Alias, f.c(RolePath), p.Project.Path, f.raw(ColorReset)))
+		case !p.Orphan():
+			sb.WriteString(fmt.Sprintf(" -> %s %s%s%s", p.Project.Name, f.c(RolePath), p.Project.Path, f.raw(ColorReset)))
+		default:
+			dir := p.WorkingDir
+			if dir == "" {
+				dir = "an unknown directory"
+			}
+			sb.WriteString(fmt.Sprintf(" %sorphan%s, started from %s%s%s, stop it with: dcm stop --orphan %s",
+				f.c(RoleWarning), f.raw(ColorReset), f.c(RolePath), dir, f.raw(ColorReset), p.Name))
+		}
+		sb.WriteString("\n")
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
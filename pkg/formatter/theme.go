@@ -0,0 +1,157 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Semantic roles that can be styled by a theme
+const (
+	RoleHeader   = "header"
+	RoleProgress = "progress"
+	RoleProject  = "project"
+	RoleManaged  = "managed"
+	RolePath     = "path"
+	RoleSuccess  = "success"
+	RoleError    = "error"
+	RoleWarning  = "warning"
+	RoleIdle     = "idle"
+	RoleRunning  = "running"
+	RoleStopped  = "stopped"
+)
+
+// Roles lists every role a theme can style
+var Roles = []string{
+	RoleHeader, RoleProgress, RoleProject, RoleManaged, RolePath,
+	RoleSuccess, RoleError, RoleWarning, RoleIdle, RoleRunning, RoleStopped,
+}
+
+// Style is the color and icon used for a role.
+// Color holds raw ANSI codes, Icon may be empty.
+type Style struct {
+	Color string
+	Icon  string
+}
+
+// Theme maps roles to styles
+type Theme map[string]Style
+
+// Built-in theme names
+const (
+	ThemeDefault = "default"
+	ThemeLight   = "light"
+	ThemePlain   = "plain"
+)
+
+// DefaultTheme is the theme used when none is configured
+var DefaultTheme = Theme{
+	RoleHeader:   {Color: ColorBold, Icon: "📋"},
+	RoleProgress: {Color: ColorBold, Icon: "🔄"},
+	RoleProject:  {Color: ColorBlue, Icon: "📁"},
+	RoleManaged:  {Color: ColorBlue, Icon: "📌"},
+	RolePath:     {},
+	RoleSuccess:  {Color: ColorGreen, Icon: "✅"},
+	RoleError:    {Color: ColorRed, Icon: "❌"},
+	RoleWarning:  {Color: ColorYellow, Icon: "❓"},
+	RoleIdle:     {Color: ColorYellow, Icon: "🛑"},
+	RoleRunning:  {Color: ColorGreen, Icon: "🟢"},
+	RoleStopped:  {Color: ColorRed, Icon: "🔴"},
+}
+
+// themes holds the built-in themes by name
+var themes = map[string]Theme{
+	ThemeDefault: DefaultTheme,
+	// light avoids yellow and white, which are unreadable on light backgrounds
+	ThemeLight: DefaultTheme.With(Theme{
+		RoleWarning: {Color: ColorPurple, Icon: "❓"},
+		RoleIdle:    {Color: ColorPurple, Icon: "🛑"},
+	}),
+	// plain keeps the colors but drops every emoji
+	ThemePlain: DefaultTheme.withoutIcons(),
+}
+
+// ThemeNames returns the names of the built-in themes
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ThemeByName returns a built-in theme
+func ThemeByName(name string) (Theme, error) {
+	if name == "" {
+		return DefaultTheme, nil
+	}
+	theme, ok := themes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme '%s' (valid themes: %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	return theme, nil
+}
+
+// With returns a copy of the theme with the given roles replaced
+func (t Theme) With(overrides Theme) Theme {
+	merged := make(Theme, len(t))
+	for role, style := range t {
+		merged[role] = style
+	}
+	for role, style := range overrides {
+		merged[role] = style
+	}
+	return merged
+}
+
+// withoutIcons returns a copy of the theme with every icon removed
+func (t Theme) withoutIcons() Theme {
+	plain := make(Theme, len(t))
+	for role, style := range t {
+		plain[role] = Style{Color: style.Color}
+	}
+	return plain
+}
+
+// colorNames maps color names accepted in config to ANSI codes
+var colorNames = map[string]string{
+	"none":    "",
+	"default": "",
+	"bold":    ColorBold,
+	"red":     ColorRed,
+	"green":   ColorGreen,
+	"yellow":  ColorYellow,
+	"blue":    ColorBlue,
+	"magenta": ColorPurple,
+	"purple":  ColorPurple,
+	"cyan":    ColorCyan,
+	"white":   ColorWhite,
+	"black":   "\033[30m",
+	"gray":    "\033[90m",
+	"grey":    "\033[90m",
+}
+
+// ParseColor converts a space separated list of color names such as
+// "bold blue" into ANSI codes
+func ParseColor(value string) (string, error) {
+	var code strings.Builder
+	for _, name := range strings.Fields(strings.ToLower(value)) {
+		c, ok := colorNames[name]
+		if !ok {
+			return "", fmt.Errorf("unknown color '%s'", name)
+		}
+		code.WriteString(c)
+	}
+	return code.String(), nil
+}
+
+// IsRole reports whether role is a known theme role
+func IsRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}